@@ -1,8 +1,568 @@
 package graph
 
-// Node is a graph node containing links to parents and child nodes
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Node is a graph node containing links to parents and child nodes. Parents are the nodes the node depends on and
+// children are the nodes depending on it.
 type Node struct {
 	ID       string
 	Parents  map[string]*Node
 	Children map[string]*Node
 }
+
+// Graph is a directed dependency graph whose edges lead from nodes to the parents they depend on. Edges carry the
+// labels of the dependencies they stand for, such as the names of foreign key constraints.
+type Graph struct {
+	nodes  map[string]*Node
+	labels map[[2]string]map[string]bool
+}
+
+// CycleError is returned when nodes cannot be sorted as their dependencies form a cycle
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("cycle %s", strings.Join(e.Path, " -> "))
+}
+
+// New returns an empty graph
+func New() *Graph {
+	return &Graph{
+		nodes:  make(map[string]*Node),
+		labels: make(map[[2]string]map[string]bool),
+	}
+}
+
+// AddNode adds a node to the graph if it does not exist and returns it
+func (g *Graph) AddNode(id string) *Node {
+	if node, ok := g.nodes[id]; ok {
+		return node
+	}
+	node := &Node{
+		ID:       id,
+		Parents:  make(map[string]*Node),
+		Children: make(map[string]*Node),
+	}
+	g.nodes[id] = node
+	return node
+}
+
+// RemoveNode removes a node along with its edges
+func (g *Graph) RemoveNode(id string) {
+	node, ok := g.nodes[id]
+	if !ok {
+		return
+	}
+	for parent := range node.Parents {
+		g.RemoveEdge(id, parent)
+	}
+	for child := range node.Children {
+		g.RemoveEdge(child, id)
+	}
+	delete(g.nodes, id)
+}
+
+// AddEdge adds an edge from child to the parent it depends on, adding both nodes if they do not exist. Labels are
+// added to the labels of an existing edge.
+func (g *Graph) AddEdge(child, parent string, labels ...string) {
+	childNode, parentNode := g.AddNode(child), g.AddNode(parent)
+	childNode.Parents[parent] = parentNode
+	parentNode.Children[child] = childNode
+
+	key := [2]string{child, parent}
+	if g.labels[key] == nil {
+		g.labels[key] = make(map[string]bool)
+	}
+	for _, label := range labels {
+		g.labels[key][label] = true
+	}
+}
+
+// RemoveEdge removes the edge from child to parent and its labels
+func (g *Graph) RemoveEdge(child, parent string) {
+	if childNode, ok := g.nodes[child]; ok {
+		delete(childNode.Parents, parent)
+	}
+	if parentNode, ok := g.nodes[parent]; ok {
+		delete(parentNode.Children, child)
+	}
+	delete(g.labels, [2]string{child, parent})
+}
+
+// Node returns the node with the id, or nil if it does not exist
+func (g *Graph) Node(id string) *Node {
+	return g.nodes[id]
+}
+
+// HasEdge returns whether there is an edge from child to parent
+func (g *Graph) HasEdge(child, parent string) bool {
+	node, ok := g.nodes[child]
+	if !ok {
+		return false
+	}
+	_, ok = node.Parents[parent]
+	return ok
+}
+
+// Labels returns the labels of the edge from child to parent in alphabetical order
+func (g *Graph) Labels(child, parent string) []string {
+	return sortedIDs(g.labels[[2]string{child, parent}])
+}
+
+// Nodes returns the ids of the nodes in alphabetical order
+func (g *Graph) Nodes() []string {
+	return sortedIDs(g.nodes)
+}
+
+// Parents returns the ids of the parents of the node in alphabetical order
+func (g *Graph) Parents(id string) []string {
+	if node, ok := g.nodes[id]; ok {
+		return sortedIDs(node.Parents)
+	}
+	return nil
+}
+
+// Children returns the ids of the children of the node in alphabetical order
+func (g *Graph) Children(id string) []string {
+	if node, ok := g.nodes[id]; ok {
+		return sortedIDs(node.Children)
+	}
+	return nil
+}
+
+func sortedIDs[V any](m map[string]V) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Sort returns the ids of the nodes in topological order, with parents before their children. Nodes whose parents
+// are all sorted are taken in rounds, each in alphabetical order, so the order is stable. A CycleError is returned
+// if the remaining nodes form a cycle.
+func (g *Graph) Sort() ([]string, error) {
+	sorted, _, err := g.sort(false)
+	return sorted, err
+}
+
+// SortBreakingCycles returns the ids of the nodes in topological order like Sort. Whenever the remaining nodes form
+// a cycle, the edge closing the cycle is ignored. It also returns the ignored edges as child and parent pairs.
+func (g *Graph) SortBreakingCycles() ([]string, [][2]string) {
+	sorted, broken, _ := g.sort(true)
+	return sorted, broken
+}
+
+func (g *Graph) sort(breakCycles bool) ([]string, [][2]string, error) {
+	// parents of each remaining node which are not sorted yet
+	remaining := make(map[string]map[string]bool)
+	for id, node := range g.nodes {
+		remaining[id] = make(map[string]bool)
+		for parent := range node.Parents {
+			if parent != id {
+				remaining[id][parent] = true
+			}
+		}
+	}
+
+	var sorted []string
+	var broken [][2]string
+	for len(remaining) > 0 {
+		var roots []string
+		for id, parents := range remaining {
+			if len(parents) == 0 {
+				roots = append(roots, id)
+			}
+		}
+
+		if len(roots) == 0 {
+			cycle := remainingCycle(remaining)
+			if !breakCycles {
+				return sorted, broken, &CycleError{Path: cycle}
+			}
+			child, parent := cycle[len(cycle)-2], cycle[len(cycle)-1]
+			delete(remaining[child], parent)
+			broken = append(broken, [2]string{child, parent})
+			continue
+		}
+
+		sort.Strings(roots)
+		for _, id := range roots {
+			for child := range g.nodes[id].Children {
+				if parents, ok := remaining[child]; ok {
+					delete(parents, id)
+				}
+			}
+			delete(remaining, id)
+		}
+		sorted = append(sorted, roots...)
+	}
+	return sorted, broken, nil
+}
+
+// SortInOrder returns the ids of the nodes in topological order, taking among the nodes whose parents are all
+// sorted the one first in order, so nodes already in a valid order keep it. Nodes missing from order come after the
+// others in alphabetical order. Whenever the remaining nodes form a cycle, the remaining node first in order is taken
+// regardless of its parents.
+func (g *Graph) SortInOrder(order []string) []string {
+	positions := make(map[string]int)
+	for _, id := range order {
+		if _, ok := g.nodes[id]; ok {
+			if _, ok := positions[id]; !ok {
+				positions[id] = len(positions)
+			}
+		}
+	}
+	for _, id := range g.Nodes() {
+		if _, ok := positions[id]; !ok {
+			positions[id] = len(positions)
+		}
+	}
+	ids := make([]string, len(positions))
+	for id, position := range positions {
+		ids[position] = id
+	}
+
+	// number of parents of each node which are not sorted yet
+	waiting := make([]int, len(ids))
+	for position, id := range ids {
+		for parent := range g.nodes[id].Parents {
+			if parent != id {
+				waiting[position]++
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(ids))
+	done := make([]bool, len(ids))
+	ready := &positionHeap{}
+	for position := range ids {
+		if waiting[position] == 0 {
+			heap.Push(ready, position)
+		}
+	}
+	for len(sorted) < len(ids) {
+		position := -1
+		if ready.Len() > 0 {
+			position = heap.Pop(ready).(int)
+		} else {
+			// the remaining nodes form a cycle
+			for position = 0; done[position]; position++ {
+			}
+		}
+		if done[position] {
+			continue
+		}
+		done[position] = true
+		id := ids[position]
+		sorted = append(sorted, id)
+		for child := range g.nodes[id].Children {
+			childPosition := positions[child]
+			if child == id || done[childPosition] {
+				continue
+			}
+			if waiting[childPosition]--; waiting[childPosition] == 0 {
+				heap.Push(ready, childPosition)
+			}
+		}
+	}
+	return sorted
+}
+
+// positionHeap is a min heap of positions in an order
+type positionHeap []int
+
+func (h positionHeap) Len() int           { return len(h) }
+func (h positionHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h positionHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *positionHeap) Push(x any) {
+	*h = append(*h, x.(int))
+}
+
+func (h *positionHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// remainingCycle returns a cycle among the remaining nodes, in which every node has a parent, as the path from the
+// first node through the parents they depend on back to a node of the path
+func remainingCycle(remaining map[string]map[string]bool) []string {
+	var path []string
+	visited := make(map[string]int)
+	for id := sortedIDs(remaining)[0]; ; {
+		if index, ok := visited[id]; ok {
+			return append(path[index:], id)
+		}
+		visited[id] = len(path)
+		path = append(path, id)
+		id = sortedIDs(remaining[id])[0]
+	}
+}
+
+// StronglyConnectedComponents returns the strongly connected components of the graph using Tarjan's algorithm. The
+// ids of each component are in alphabetical order and components come after the components they depend on.
+func (g *Graph) StronglyConnectedComponents() [][]string {
+	index := 0
+	indices := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var connect func(id string)
+	connect = func(id string) {
+		indices[id], lowLinks[id] = index, index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, parent := range g.Parents(id) {
+			if _, ok := indices[parent]; !ok {
+				connect(parent)
+				lowLinks[id] = min(lowLinks[id], lowLinks[parent])
+			} else if onStack[parent] {
+				lowLinks[id] = min(lowLinks[id], indices[parent])
+			}
+		}
+
+		if lowLinks[id] == indices[id] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == id {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, id := range g.Nodes() {
+		if _, ok := indices[id]; !ok {
+			connect(id)
+		}
+	}
+	return components
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// FindCycle returns a cycle of the graph as the path from a node through the parents it depends on back to itself,
+// or nil if the graph has no cycles. Self references are cycles of a single node.
+func (g *Graph) FindCycle() []string {
+	for _, component := range g.StronglyConnectedComponents() {
+		if len(component) == 1 && !g.HasEdge(component[0], component[0]) {
+			continue
+		}
+		members := make(map[string]bool)
+		for _, id := range component {
+			members[id] = true
+		}
+
+		// every node of the component has a parent in it, so the walk returns to a node of the path
+		var path []string
+		visited := make(map[string]int)
+		for id := component[0]; ; {
+			if index, ok := visited[id]; ok {
+				return append(path[index:], id)
+			}
+			visited[id] = len(path)
+			path = append(path, id)
+			for _, parent := range g.Parents(id) {
+				if members[parent] {
+					id = parent
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Closure returns the ids of the nodes and every node they transitively depend on in alphabetical order. Ids which
+// are not in the graph are left out.
+func (g *Graph) Closure(ids ...string) []string {
+	return g.reach(ids, -1, func(node *Node) map[string]*Node { return node.Parents })
+}
+
+// Dependents returns the ids of the nodes and the nodes transitively depending on them up to depth edges away in
+// alphabetical order, or at any distance if depth is negative. Ids which are not in the graph are left out.
+func (g *Graph) Dependents(depth int, ids ...string) []string {
+	return g.reach(ids, depth, func(node *Node) map[string]*Node { return node.Children })
+}
+
+func (g *Graph) reach(ids []string, depth int, next func(*Node) map[string]*Node) []string {
+	reached := make(map[string]bool)
+	var frontier []string
+	for _, id := range ids {
+		if _, ok := g.nodes[id]; ok && !reached[id] {
+			reached[id] = true
+			frontier = append(frontier, id)
+		}
+	}
+	for hop := 0; len(frontier) > 0 && (depth < 0 || hop < depth); hop++ {
+		var nextFrontier []string
+		for _, id := range frontier {
+			for neighbour := range next(g.nodes[id]) {
+				if !reached[neighbour] {
+					reached[neighbour] = true
+					nextFrontier = append(nextFrontier, neighbour)
+				}
+			}
+		}
+		frontier = nextFrontier
+	}
+	return sortedIDs(reached)
+}
+
+// ShortestPath returns the ids of the nodes on a shortest path from one node to another, following edges from
+// children to parents, or in both directions if undirected is set. Among paths of equal length the one through
+// alphabetically first nodes is returned. It returns nil if there is no path.
+func (g *Graph) ShortestPath(from, to string, undirected bool) []string {
+	if _, ok := g.nodes[from]; !ok {
+		return nil
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return nil
+	}
+
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 && queue[0] != to {
+		id := queue[0]
+		queue = queue[1:]
+		neighbours := g.Parents(id)
+		if undirected {
+			neighbours = append(neighbours, g.Children(id)...)
+			sort.Strings(neighbours)
+		}
+		for _, neighbour := range neighbours {
+			if _, ok := previous[neighbour]; !ok {
+				previous[neighbour] = id
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+
+	path := []string{to}
+	for id := to; id != from; {
+		id = previous[id]
+		path = append([]string{id}, path...)
+	}
+	return path
+}
+
+// ShortestPaths returns every shortest path from one node to another like ShortestPath, with the paths in
+// alphabetical order of their nodes. It returns nil if there is no path.
+func (g *Graph) ShortestPaths(from, to string, undirected bool) [][]string {
+	if _, ok := g.nodes[from]; !ok {
+		return nil
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return nil
+	}
+
+	// record every neighbour preceding a node on a shortest path to it
+	distances := map[string]int{from: 0}
+	previous := make(map[string][]string)
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if distance, ok := distances[to]; ok && distances[id] >= distance {
+			break
+		}
+		neighbours := g.Parents(id)
+		if undirected {
+			neighbours = append(neighbours, g.Children(id)...)
+			sort.Strings(neighbours)
+		}
+		for i, neighbour := range neighbours {
+			if i > 0 && neighbour == neighbours[i-1] {
+				continue
+			}
+			distance, ok := distances[neighbour]
+			if !ok {
+				distances[neighbour] = distances[id] + 1
+				queue = append(queue, neighbour)
+			} else if distance != distances[id]+1 {
+				continue
+			}
+			previous[neighbour] = append(previous[neighbour], id)
+		}
+	}
+	if _, ok := distances[to]; !ok {
+		return nil
+	}
+
+	var paths [][]string
+	var walk func(id string, path []string)
+	walk = func(id string, path []string) {
+		path = append([]string{id}, path...)
+		if id == from {
+			paths = append(paths, path)
+			return
+		}
+		for _, prev := range previous[id] {
+			walk(prev, path)
+		}
+	}
+	walk(to, nil)
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.Join(paths[i], "\x00") < strings.Join(paths[j], "\x00")
+	})
+	return paths
+}
+
+// Components returns the weakly connected components of the graph, the nodes connected by edges in either
+// direction. The ids of each component are in alphabetical order and the components are ordered by their first id.
+func (g *Graph) Components() [][]string {
+	var components [][]string
+	visited := make(map[string]bool)
+	for _, id := range g.Nodes() {
+		if visited[id] {
+			continue
+		}
+		var component []string
+		stack := []string{id}
+		visited[id] = true
+		for len(stack) > 0 {
+			current := g.nodes[stack[len(stack)-1]]
+			stack = stack[:len(stack)-1]
+			component = append(component, current.ID)
+			for _, neighbours := range []map[string]*Node{current.Parents, current.Children} {
+				for neighbour := range neighbours {
+					if !visited[neighbour] {
+						visited[neighbour] = true
+						stack = append(stack, neighbour)
+					}
+				}
+			}
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+	return components
+}
@@ -0,0 +1,242 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// newGraph returns a graph with edges given as child and parent pairs
+func newGraph(nodes []string, edges [][2]string) *Graph {
+	g := New()
+	for _, id := range nodes {
+		g.AddNode(id)
+	}
+	for _, edge := range edges {
+		g.AddEdge(edge[0], edge[1], edge[0]+"_"+edge[1])
+	}
+	return g
+}
+
+func TestEdges(t *testing.T) {
+	g := New()
+	g.AddEdge("orders", "users", "orders_buyer_fkey")
+	g.AddEdge("orders", "users", "orders_seller_fkey")
+	g.AddEdge("items", "orders")
+
+	if !cmp.Equal(g.Nodes(), []string{"items", "orders", "users"}) {
+		t.Error("Add edges - nodes error")
+	}
+	if !cmp.Equal(g.Labels("orders", "users"), []string{"orders_buyer_fkey", "orders_seller_fkey"}) {
+		t.Error("Add edges - labels error")
+	}
+	if !cmp.Equal(g.Parents("orders"), []string{"users"}) || !cmp.Equal(g.Children("orders"), []string{"items"}) {
+		t.Error("Add edges - links error")
+	}
+
+	g.RemoveEdge("orders", "users")
+	if g.HasEdge("orders", "users") || len(g.Labels("orders", "users")) != 0 || len(g.Children("users")) != 0 {
+		t.Error("Remove edge - error")
+	}
+
+	g.RemoveNode("orders")
+	if g.Node("orders") != nil || len(g.Parents("items")) != 0 {
+		t.Error("Remove node - error")
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		name           string
+		nodes          []string
+		edges          [][2]string
+		expectedSorted []string
+		expectedBroken [][2]string
+		expectedCycle  []string
+	}{
+		{
+			name:           "Rounds in alphabetical order",
+			nodes:          []string{"d"},
+			edges:          [][2]string{{"c", "a"}, {"b", "a"}, {"e", "c"}},
+			expectedSorted: []string{"a", "d", "b", "c", "e"},
+		},
+		{
+			name:           "Self reference",
+			edges:          [][2]string{{"a", "a"}, {"b", "a"}},
+			expectedSorted: []string{"a", "b"},
+		},
+		{
+			name:           "Cycle",
+			nodes:          []string{"x"},
+			edges:          [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}, {"d", "c"}},
+			expectedSorted: []string{"x", "c", "b", "d", "a"},
+			expectedBroken: [][2]string{{"c", "a"}},
+			expectedCycle:  []string{"a", "b", "c", "a"},
+		},
+	}
+	for _, test := range tests {
+		g := newGraph(test.nodes, test.edges)
+		sorted, err := g.Sort()
+		if len(test.expectedCycle) == 0 {
+			if err != nil || !cmp.Equal(sorted, test.expectedSorted) {
+				t.Error(test.name + " - sort error")
+			}
+		} else if cycle, ok := err.(*CycleError); !ok || !cmp.Equal(cycle.Path, test.expectedCycle) {
+			t.Error(test.name + " - cycle error")
+		}
+
+		sorted, broken := g.SortBreakingCycles()
+		if !cmp.Equal(sorted, test.expectedSorted) || !cmp.Equal(broken, test.expectedBroken) {
+			t.Error(test.name + " - sort breaking cycles error")
+		}
+	}
+}
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	g := newGraph([]string{"e"}, [][2]string{{"a", "b"}, {"b", "a"}, {"c", "a"}, {"c", "d"}, {"d", "d"}})
+	expected := [][]string{{"a", "b"}, {"d"}, {"c"}, {"e"}}
+	if !cmp.Equal(g.StronglyConnectedComponents(), expected) {
+		t.Error("Strongly connected components - error")
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		edges    [][2]string
+		expected []string
+	}{
+		{name: "No cycle", edges: [][2]string{{"a", "b"}, {"b", "c"}}},
+		{name: "Self reference", edges: [][2]string{{"a", "b"}, {"b", "b"}}, expected: []string{"b", "b"}},
+		{
+			name:     "Cycle",
+			edges:    [][2]string{{"x", "a"}, {"a", "b"}, {"b", "c"}, {"c", "a"}},
+			expected: []string{"a", "b", "c", "a"},
+		},
+	}
+	for _, test := range tests {
+		if !cmp.Equal(newGraph(nil, test.edges).FindCycle(), test.expected) {
+			t.Error(test.name + " - error")
+		}
+	}
+}
+
+func TestReachability(t *testing.T) {
+	g := newGraph([]string{"z"}, [][2]string{{"items", "orders"}, {"orders", "users"}, {"notes", "items"},
+		{"orders", "shops"}})
+
+	if !cmp.Equal(g.Closure("items", "missing"), []string{"items", "orders", "shops", "users"}) {
+		t.Error("Closure - error")
+	}
+	if !cmp.Equal(g.Dependents(1, "users"), []string{"orders", "users"}) {
+		t.Error("Dependents one hop - error")
+	}
+	if !cmp.Equal(g.Dependents(-1, "users"), []string{"items", "notes", "orders", "users"}) {
+		t.Error("Dependents - error")
+	}
+	if !cmp.Equal(g.Components(), [][]string{{"items", "notes", "orders", "shops", "users"}, {"z"}}) {
+		t.Error("Components - error")
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	g := newGraph([]string{"z"}, [][2]string{{"items", "orders"}, {"orders", "users"}, {"items", "products"},
+		{"reviews", "products"}, {"reviews", "users"}})
+
+	tests := []struct {
+		name       string
+		from       string
+		to         string
+		undirected bool
+		expected   []string
+	}{
+		{name: "Directed", from: "items", to: "users", expected: []string{"items", "orders", "users"}},
+		{name: "Directed unreachable", from: "users", to: "items"},
+		{name: "Undirected", from: "users", to: "products", undirected: true,
+			expected: []string{"users", "reviews", "products"}},
+		{name: "Same node", from: "users", to: "users", expected: []string{"users"}},
+		{name: "Disconnected", from: "users", to: "z", undirected: true},
+		{name: "Missing node", from: "users", to: "missing", undirected: true},
+	}
+	for _, test := range tests {
+		if !cmp.Equal(g.ShortestPath(test.from, test.to, test.undirected), test.expected) {
+			t.Error(test.name + " - error")
+		}
+	}
+}
+
+func TestSortInOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    []string
+		edges    [][2]string
+		expected []string
+	}{
+		{
+			name:     "Valid order kept",
+			order:    []string{"c", "b", "a"},
+			edges:    [][2]string{{"a", "b"}},
+			expected: []string{"c", "b", "a"},
+		},
+		{
+			name:     "Dependencies moved ahead",
+			order:    []string{"table", "view", "function"},
+			edges:    [][2]string{{"table", "function"}, {"view", "table"}},
+			expected: []string{"function", "table", "view"},
+		},
+		{
+			name:     "Missing nodes last",
+			order:    []string{"b"},
+			edges:    [][2]string{{"b", "c"}, {"a", "c"}},
+			expected: []string{"c", "b", "a"},
+		},
+		{
+			name:     "Cycle",
+			order:    []string{"a", "b", "c"},
+			edges:    [][2]string{{"a", "b"}, {"b", "a"}, {"c", "a"}},
+			expected: []string{"a", "b", "c"},
+		},
+	}
+	for _, test := range tests {
+		g := newGraph(test.order, test.edges)
+		if !cmp.Equal(g.SortInOrder(test.order), test.expected) {
+			t.Error(test.name + " - error")
+		}
+	}
+}
+
+func TestShortestPaths(t *testing.T) {
+	g := newGraph([]string{"z"}, [][2]string{{"items", "orders"}, {"orders", "users"}, {"items", "products"},
+		{"reviews", "products"}, {"reviews", "users"}, {"users", "users"}, {"products", "shops"}, {"shops", "users"}})
+
+	tests := []struct {
+		name       string
+		from       string
+		to         string
+		undirected bool
+		expected   [][]string
+	}{
+		{name: "Directed", from: "items", to: "users", expected: [][]string{{"items", "orders", "users"}}},
+		{
+			name:       "Undirected",
+			from:       "items",
+			to:         "users",
+			undirected: true,
+			expected:   [][]string{{"items", "orders", "users"}},
+		},
+		{
+			name:       "Several shortest",
+			from:       "users",
+			to:         "products",
+			undirected: true,
+			expected:   [][]string{{"users", "reviews", "products"}, {"users", "shops", "products"}},
+		},
+		{name: "Same node", from: "users", to: "users", expected: [][]string{{"users"}}},
+		{name: "Disconnected", from: "users", to: "z", undirected: true},
+	}
+	for _, test := range tests {
+		if !cmp.Equal(g.ShortestPaths(test.from, test.to, test.undirected), test.expected) {
+			t.Error(test.name + " - error")
+		}
+	}
+}
@@ -2,21 +2,68 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/jchiam/psql-schema-dump-sanitiser/parse"
 )
 
-func main() {
-	if len(os.Args)-1 == 0 {
-		log.Fatal("Missing argument: \"postgres-dump-sanitiser <file>\"")
-		return
+// filterList collects the filters given by a repeatable flag
+type filterList []*parse.Filter
+
+func (l *filterList) String() string {
+	return fmt.Sprint(len(*l), " filters")
+}
+
+func (l *filterList) Set(s string) error {
+	filter, err := parse.ParseFilter(s)
+	if err != nil {
+		return err
 	}
+	*l = append(*l, filter)
+	return nil
+}
+
+// addPrintFlags registers the flags controlling how the schema is printed into options
+func addPrintFlags(flags *flag.FlagSet, options *parse.PrintOptions) {
+	flags.BoolVar(&options.ShowCredentials, "show-credentials", false,
+		"print user mapping and subscription credentials instead of redacting them")
+	flags.StringVar(&options.TypeStyle, "type-style", "",
+		"normalise column types to SQL \"standard\" names or \"short\" aliases")
+	flags.BoolVar(&options.CollapseSerials, "collapse-serials", false,
+		"print columns with owned sequences and nextval defaults as serial columns")
+	flags.StringVar(&options.ColumnOrder, "column-order", parse.ColumnOrderGrouped,
+		"print columns \"grouped\" by primary and foreign keys, in \"physical\" order or in \"alphabetical\" order")
+	flags.StringVar(&options.TableOrder, "table-order", parse.TableOrderTopological, "print tables in \"topological\" "+
+		"order of foreign keys, in \"alphabetical\" or \"dump\" order with foreign keys added afterwards, or grouped by "+
+		"connected \"component\"")
+	flags.BoolVar(&options.BreakCycles, "break-cycles", false,
+		"add foreign keys forming cycles after the tables instead of failing")
+}
 
-	// prepare file and reader
-	filePath := os.Args[1]
+// validatePrintOptions checks the values of the print flags
+func validatePrintOptions(options *parse.PrintOptions) error {
+	if options.TypeStyle != "" && options.TypeStyle != parse.TypeStyleStandard &&
+		options.TypeStyle != parse.TypeStyleShort {
+		return fmt.Errorf("unknown type style %q", options.TypeStyle)
+	}
+	if options.ColumnOrder != parse.ColumnOrderGrouped && options.ColumnOrder != parse.ColumnOrderPhysical &&
+		options.ColumnOrder != parse.ColumnOrderAlphabetical {
+		return fmt.Errorf("unknown column order %q", options.ColumnOrder)
+	}
+	if options.TableOrder != parse.TableOrderTopological && options.TableOrder != parse.TableOrderAlphabetical &&
+		options.TableOrder != parse.TableOrderDump && options.TableOrder != parse.TableOrderComponent {
+		return fmt.Errorf("unknown table order %q", options.TableOrder)
+	}
+	return nil
+}
+
+// readSchema reads and processes the schema dump at filePath, logging any unprocessed lines
+func readSchema(filePath string) *parse.Schema {
 	file, err := os.Open(filePath)
 	if err != nil {
 		log.Fatal(err)
@@ -27,78 +74,259 @@ func main() {
 			log.Fatal(cerr)
 		}
 	}()
-	reader := bufio.NewReader(file)
-
-	var lines []string
-	// read file by line till EOF
-	for {
-		line, eof := parse.ReadLine(reader)
-		if eof {
-			break
+
+	lines, schema, err := parse.ReadSchema(bufio.NewReader(file))
+	if err != nil {
+		for _, l := range lines {
+			log.Println(l)
 		}
+		log.Fatal(err)
+	}
+	return schema
+}
 
-		// 1. Check for redundant lines
-		if parse.IsRedundant(line) {
-			continue
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "subset":
+			subset(os.Args[2:])
+			return
+		case "path":
+			path(os.Args[2:])
+			return
+		case "impact":
+			impact(os.Args[2:])
+			return
+		case "diff":
+			diff(os.Args[2:])
+			return
+		case "migrate":
+			migrate(os.Args[2:])
+			return
+		case "check":
+			check(os.Args[2:])
+			return
 		}
-		lines = append(lines, line)
 	}
 
-	// 2. Group and map table statements
-	tables, lines := parse.MapTables(lines)
+	options := &parse.PrintOptions{}
+	addPrintFlags(flag.CommandLine, options)
+	excludeTables := flag.String("exclude-tables", parse.DefaultExcludedTables, "comma separated tables to leave out, "+
+		"given by name, by schema and name, or as the migration tool presets gorp, goose, golang-migrate, flyway, "+
+		"liquibase, django, rails and sqitch")
+	var includes, excludes filterList
+	flag.Var(&includes, "include", "repeatable [kind:][schema.]name glob or [kind:]/regexp/ of the objects to keep, "+
+		"where kind is table, sequence, function, procedure, trigger, view, aggregate, collation, foreign-table, type or "+
+		"domain")
+	flag.Var(&excludes, "exclude", "repeatable [kind:][schema.]name glob or [kind:]/regexp/ of the objects to leave out")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatal("Missing argument: \"postgres-dump-sanitiser [options] <file>\"")
+		return
+	}
+	if err := validatePrintOptions(options); err != nil {
+		log.Fatal(err)
+	}
 
-	// 3. Squash any multi-line statements to single line
-	lines = parse.SquashMultiLineStatements(lines)
+	schema := readSchema(flag.Arg(0))
 
-	// 4. Squash sequence statements into create sequence statements and map to tables
-	lines, err = parse.MapSequences(lines, tables)
-	if err != nil {
+	// 17. Exclude migration bookkeeping and other unwanted tables
+	parse.ExcludeTables(schema, strings.Split(*excludeTables, ","))
+
+	// 18. Filter objects by the include and exclude patterns
+	for _, warning := range parse.FilterSchema(schema, includes, excludes) {
+		log.Println("warning:", warning)
+	}
+
+	// 19. Print
+	if err := parse.PrintSchema(schema, options); err != nil {
 		log.Fatal(err)
 	}
+}
 
-	// 5. Store sequences not owned by table columns
-	lines, seqs, err := parse.StoreSequences(lines)
-	if err != nil {
+// subset prints the root tables given by the arguments along with the tables they need as a standalone schema
+func subset(args []string) {
+	flags := flag.NewFlagSet("subset", flag.ExitOnError)
+	options := &parse.PrintOptions{}
+	addPrintFlags(flags, options)
+	tables := flags.String("tables", "", "comma separated root tables, given by name or by schema and name")
+	referencing := flags.Int("referencing", 0, "add tables referencing the subset by foreign keys up to this many hops away")
+	if err := flags.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+	if flags.NArg() == 0 || len(*tables) == 0 {
+		log.Fatal("Missing argument: \"postgres-dump-sanitiser subset -tables <tables> [options] <file>\"")
+		return
+	}
+	if err := validatePrintOptions(options); err != nil {
 		log.Fatal(err)
 	}
 
-	// 6. Add default values to columns
-	lines, err = parse.MapDefaultValues(lines, tables)
-	if err != nil {
+	schema := readSchema(flags.Arg(0))
+	if err := parse.SubsetSchema(schema, strings.Split(*tables, ","), *referencing); err != nil {
+		log.Fatal(err)
+	}
+	if err := parse.PrintSchema(schema, options); err != nil {
 		log.Fatal(err)
 	}
+}
 
-	// 7. Map constraint statements to tables
-	lines, err = parse.MapConstraints(lines, tables)
-	if err != nil {
+// path prints the shortest join paths between the tables given by the arguments
+func path(args []string) {
+	flags := flag.NewFlagSet("path", flag.ExitOnError)
+	from := flags.String("from", "", "table the join paths start from, given by name or by schema and name")
+	to := flags.String("to", "", "table the join paths end at, given by name or by schema and name")
+	selects := flags.Bool("select", false, "print a select statement joining the tables of each path")
+	if err := flags.Parse(args); err != nil {
 		log.Fatal(err)
 	}
+	if flags.NArg() == 0 || len(*from) == 0 || len(*to) == 0 {
+		log.Fatal("Missing argument: \"postgres-dump-sanitiser path -from <table> -to <table> [-select] <file>\"")
+		return
+	}
 
-	// 8. Map index statements to tables
-	lines, err = parse.MapIndices(lines, tables)
+	schema := readSchema(flags.Arg(0))
+	paths, err := parse.JoinPaths(schema.Tables, *from, *to)
 	if err != nil {
 		log.Fatal(err)
 	}
+	for i, joinPath := range paths {
+		if !*selects {
+			fmt.Println(joinPath)
+			continue
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("-- %s\n%s\n", joinPath, joinPath.Select())
+	}
+}
+
+// impact prints the objects referencing the column given by the arguments
+func impact(args []string) {
+	flags := flag.NewFlagSet("impact", flag.ExitOnError)
+	column := flags.String("column", "", "column to analyse, given as table.column or schema.table.column")
+	if err := flags.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+	if flags.NArg() == 0 || len(*column) == 0 {
+		log.Fatal("Missing argument: \"postgres-dump-sanitiser impact -column <table.column> <file>\"")
+		return
+	}
 
-	// 9. Store functions
-	lines, functions, err := parse.StoreFunctions(lines)
+	schema := readSchema(flags.Arg(0))
+	impacts, err := parse.ColumnImpact(schema, *column)
 	if err != nil {
 		log.Fatal(err)
 	}
+	for _, i := range impacts {
+		fmt.Println(i)
+	}
+}
 
-	// 10. Store triggers and trigger functions
-	lines, triggers, err := parse.StoreTriggers(lines)
+// diff prints the changes between the schema dumps given by the arguments
+func diff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "print changes as \"text\" lines or as a \"json\" array")
+	if err := flags.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+	if flags.NArg() < 2 {
+		log.Fatal("Missing argument: \"postgres-dump-sanitiser diff [-format text|json] <old file> <new file>\"")
+		return
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	changes := parse.DiffSchemas(readSchema(flags.Arg(0)), readSchema(flags.Arg(1)))
+	if *format == "json" {
+		if changes == nil {
+			changes = []*parse.Change{}
+		}
+		out, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+}
+
+// migrate prints the statements migrating the old schema dump given by the arguments to the new one
+func migrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	options := &parse.MigrateOptions{}
+	flags.StringVar(&options.Destructive, "destructive", parse.DestructiveComment, "\"allow\", \"comment\" out or "+
+		"\"refuse\" statements dropping tables, columns and sequences")
+	flags.BoolVar(&options.ShowCredentials, "show-credentials", false,
+		"create user mappings and subscriptions with their credentials instead of redacting them")
+	if err := flags.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+	if flags.NArg() < 2 {
+		log.Fatal("Missing argument: \"postgres-dump-sanitiser migrate [options] <old file> <new file>\"")
+		return
+	}
+	if options.Destructive != parse.DestructiveAllow && options.Destructive != parse.DestructiveComment &&
+		options.Destructive != parse.DestructiveRefuse {
+		log.Fatalf("unknown destructive statement handling %q", options.Destructive)
+	}
+
+	statements, err := parse.MigrateSchema(readSchema(flags.Arg(0)), readSchema(flags.Arg(1)), options)
 	if err != nil {
 		log.Fatal(err)
 	}
+	fmt.Println(strings.Join(statements, "\n\n"))
+}
 
-	if len(lines) != 0 {
-		for _, l := range lines {
-			log.Println(l)
+// check prints the changes between the schema dumps given by the arguments classified by severity, failing if any
+// change is more severe than the threshold
+func check(args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	threshold := flags.String("threshold", parse.SeverityRisky, "most severe change allowed, \"safe\", \"risky\" "+
+		"or \"breaking\"")
+	format := flags.String("format", "text", "print findings as \"text\" lines or as a \"json\" array")
+	if err := flags.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+	if flags.NArg() < 2 {
+		log.Fatal("Missing argument: \"postgres-dump-sanitiser check [options] <old file> <new file>\"")
+		return
+	}
+	if *threshold != parse.SeveritySafe && *threshold != parse.SeverityRisky && *threshold != parse.SeverityBreaking {
+		log.Fatalf("unknown threshold %q", *threshold)
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	findings := parse.CheckSchemas(readSchema(flags.Arg(0)), readSchema(flags.Arg(1)))
+	if *format == "json" {
+		if findings == nil {
+			findings = []*parse.Finding{}
+		}
+		out, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, finding := range findings {
+			fmt.Println(finding)
 		}
-		log.Fatal(fmt.Errorf("%d unprocessed lines remaining", len(lines)))
 	}
 
-	// 9. Print
-	parse.PrintSchema(tables, seqs, functions, triggers)
+	exceeding := 0
+	for _, finding := range findings {
+		if finding.Exceeds(*threshold) {
+			exceeding++
+		}
+	}
+	if exceeding > 0 {
+		log.Fatalf("%d changes more severe than %s", exceeding, *threshold)
+	}
 }
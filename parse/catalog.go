@@ -0,0 +1,325 @@
+package parse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Aggregate is the struct containing logical aspects of an aggregate function definition
+type Aggregate struct {
+	Name      string
+	Schema    string
+	Arguments []string
+	Options   map[string]string
+	Statement string
+}
+
+// Operator is the struct containing logical aspects of an operator definition
+type Operator struct {
+	Name      string
+	Schema    string
+	Options   map[string]string
+	Statement string
+}
+
+// Cast is the struct containing logical aspects of a cast definition
+type Cast struct {
+	Source    string
+	Target    string
+	Function  string
+	Context   string
+	Statement string
+}
+
+// Collation is the struct containing logical aspects of a collation definition
+type Collation struct {
+	Name      string
+	Schema    string
+	Options   map[string]string
+	Statement string
+}
+
+// aggregateFunctionOptions are the aggregate options referencing support functions
+var aggregateFunctionOptions = []string{
+	"sfunc", "finalfunc", "combinefunc", "serialfunc", "deserialfunc", "msfunc", "minvfunc", "mfinalfunc",
+}
+
+// parseOptions parses a parenthesised list of "key = value" options into a map with lower case keys
+func parseOptions(s string) map[string]string {
+	options := make(map[string]string)
+	open := strings.Index(s, "(")
+	if open == -1 {
+		return options
+	}
+	closing := matchingParen(s, open)
+	if closing == -1 {
+		return options
+	}
+	for _, option := range splitTopLevel(s[open+1:closing], ',') {
+		keyValue := strings.SplitN(option, "=", 2)
+		key := strings.ToLower(strings.TrimSpace(keyValue[0]))
+		if len(keyValue) == 2 {
+			options[key] = strings.TrimSpace(keyValue[1])
+		} else {
+			options[key] = ""
+		}
+	}
+	return options
+}
+
+// Signature returns the qualified name and argument types of the aggregate, which identifies it among its overloads
+func (a *Aggregate) Signature() string {
+	var types []string
+	for _, arg := range a.Arguments {
+		_, _, argType := splitArgument(arg)
+		types = append(types, argType)
+	}
+	return qualifiedName(a.Name, a.Schema) + "(" + strings.Join(types, ", ") + ")"
+}
+
+// Functions returns the names of the support functions the aggregate is built from
+func (a *Aggregate) Functions() []string {
+	var functions []string
+	for _, option := range aggregateFunctionOptions {
+		if function, ok := a.Options[option]; ok {
+			name, _ := removeAccessModifier(function)
+			functions = append(functions, name)
+		}
+	}
+	return functions
+}
+
+// Signature returns the qualified name and operand types of the operator, which identifies it among its overloads
+func (o *Operator) Signature() string {
+	left, right := "NONE", "NONE"
+	if arg, ok := o.Options["leftarg"]; ok {
+		left = arg
+	}
+	if arg, ok := o.Options["rightarg"]; ok {
+		right = arg
+	}
+	return qualifiedName(o.Name, o.Schema) + "(" + left + ", " + right + ")"
+}
+
+// Function returns the name of the function implementing the operator
+func (o *Operator) Function() string {
+	function, ok := o.Options["function"]
+	if !ok {
+		// PostgreSQL versions before 13 use PROCEDURE instead of FUNCTION
+		function = o.Options["procedure"]
+	}
+	name, _ := removeAccessModifier(function)
+	return name
+}
+
+// Signature returns the source and target types of the cast, which identifies it
+func (c *Cast) Signature() string {
+	return "(" + c.Source + " AS " + c.Target + ")"
+}
+
+// StoreAggregates parses sql statements for aggregates and maps them by signature.
+// It then returns the remaining lines and aggregates.
+func StoreAggregates(lines []string) ([]string, map[string]*Aggregate, error) {
+	aggregates := make(map[string]*Aggregate)
+	if len(lines) == 0 {
+		return lines, aggregates, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE AGGREGATE ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		rest := line[len("CREATE AGGREGATE "):]
+		open := strings.Index(rest, "(")
+		closing := matchingParen(rest, open)
+		if open == -1 || closing == -1 {
+			return lines, aggregates, fmt.Errorf("storing aggregates - missing aggregate arguments")
+		}
+		aggregate := &Aggregate{
+			Arguments: splitTopLevel(rest[open+1:closing], ','),
+			Options:   parseOptions(rest[closing+1:]),
+			Statement: line,
+		}
+		aggregate.Name, aggregate.Schema = removeAccessModifier(strings.TrimSpace(rest[:open]))
+		if aggregate.Schema == "public" {
+			aggregate.Statement = strings.Replace(line, aggregate.Schema+".", "", -1)
+		}
+		if len(aggregate.Arguments) == 1 && aggregate.Arguments[0] == "*" {
+			aggregate.Arguments = nil
+		}
+
+		signature := aggregate.Signature()
+		if _, ok := aggregates[signature]; ok {
+			return lines, aggregates, fmt.Errorf("storing aggregates - duplicate aggregate %s", signature)
+		}
+		aggregates[signature] = aggregate
+	}
+
+	return bufferLines, aggregates, nil
+}
+
+// StoreOperators parses sql statements for operators and maps them by signature.
+// It then returns the remaining lines and operators.
+func StoreOperators(lines []string) ([]string, map[string]*Operator, error) {
+	operators := make(map[string]*Operator)
+	if len(lines) == 0 {
+		return lines, operators, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE OPERATOR ") || strings.HasPrefix(line, "CREATE OPERATOR CLASS ") ||
+			strings.HasPrefix(line, "CREATE OPERATOR FAMILY ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		rest := line[len("CREATE OPERATOR "):]
+		open := strings.Index(rest, " (")
+		if open == -1 {
+			return lines, operators, fmt.Errorf("storing operators - missing operator options")
+		}
+		operator := &Operator{
+			Options:   parseOptions(rest[open:]),
+			Statement: line,
+		}
+		// operator names may contain dots, so only strip a schema name followed by a dot
+		operator.Name = rest[:open]
+		if index := strings.Index(operator.Name, "."); index > 0 && isIdentifier(operator.Name[:index]) {
+			operator.Schema = operator.Name[:index]
+			operator.Name = operator.Name[index+1:]
+		}
+		if operator.Schema == "public" {
+			operator.Statement = strings.Replace(line, operator.Schema+".", "", -1)
+		}
+		if len(operator.Function()) == 0 {
+			return lines, operators, fmt.Errorf("storing operators - missing operator function")
+		}
+
+		signature := operator.Signature()
+		if _, ok := operators[signature]; ok {
+			return lines, operators, fmt.Errorf("storing operators - duplicate operator %s", signature)
+		}
+		operators[signature] = operator
+	}
+
+	return bufferLines, operators, nil
+}
+
+// StoreCasts parses sql statements for casts and maps them by source and target types.
+// It then returns the remaining lines and casts.
+func StoreCasts(lines []string) ([]string, map[string]*Cast, error) {
+	casts := make(map[string]*Cast)
+	if len(lines) == 0 {
+		return lines, casts, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE CAST ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		open := strings.Index(line, "(")
+		closing := matchingParen(line, open)
+		if open == -1 || closing == -1 {
+			return lines, casts, fmt.Errorf("storing casts - missing cast types")
+		}
+		types := strings.SplitN(line[open+1:closing], " AS ", 2)
+		if len(types) != 2 {
+			return lines, casts, fmt.Errorf("storing casts - missing cast types")
+		}
+		cast := &Cast{Statement: line}
+		modifiers := make([]string, 3)
+		cast.Source, modifiers[0] = removeAccessModifier(strings.TrimSpace(types[0]))
+		cast.Target, modifiers[1] = removeAccessModifier(strings.TrimSpace(types[1]))
+
+		tail := strings.TrimSuffix(line[closing+1:], ";")
+		if index := strings.Index(tail, "WITH FUNCTION "); index != -1 {
+			function := tail[index+len("WITH FUNCTION "):]
+			if end := strings.Index(function, "("); end != -1 {
+				function = function[:end]
+			}
+			cast.Function, modifiers[2] = removeAccessModifier(strings.TrimSpace(function))
+		}
+		if index := strings.Index(tail, " AS "); index != -1 {
+			cast.Context = strings.TrimSpace(tail[index+len(" AS "):])
+		}
+
+		for _, modifier := range modifiers {
+			if len(modifier) > 0 {
+				cast.Statement = strings.Replace(cast.Statement, modifier+".", "", -1)
+			}
+		}
+
+		signature := cast.Signature()
+		if _, ok := casts[signature]; ok {
+			return lines, casts, fmt.Errorf("storing casts - duplicate cast %s", signature)
+		}
+		casts[signature] = cast
+	}
+
+	return bufferLines, casts, nil
+}
+
+// StoreCollations parses sql statements for collations and maps them by qualified name.
+// It then returns the remaining lines and collations.
+func StoreCollations(lines []string) ([]string, map[string]*Collation, error) {
+	collations := make(map[string]*Collation)
+	if len(lines) == 0 {
+		return lines, collations, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE COLLATION ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		tokens := strings.Split(line, " ")
+		collation := &Collation{
+			Options:   parseOptions(line),
+			Statement: line,
+		}
+		collation.Name, collation.Schema = removeAccessModifier(strings.TrimSuffix(tokens[2], ";"))
+		if collation.Schema == "public" {
+			collation.Statement = strings.Replace(line, collation.Schema+".", "", -1)
+		}
+
+		name := qualifiedName(collation.Name, collation.Schema)
+		if _, ok := collations[name]; ok {
+			return lines, collations, fmt.Errorf("storing collations - duplicate collation %s", name)
+		}
+		collations[name] = collation
+	}
+
+	return bufferLines, collations, nil
+}
+
+func isIdentifier(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := range s {
+		if isWordBoundary(s, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of a map of parsed objects in alphabetical order
+func sortedKeys[V any](objects map[string]V) []string {
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
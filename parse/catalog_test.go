@@ -0,0 +1,337 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStoreAggregates(t *testing.T) {
+	aggregate := "CREATE AGGREGATE public.array_accum(anycompatible) ( SFUNC = array_append, STYPE = anycompatiblearray, INITCOND = '{}' );"
+	expectedAggregate := &Aggregate{
+		Name:      "array_accum",
+		Schema:    "public",
+		Arguments: []string{"anycompatible"},
+		Options:   map[string]string{"sfunc": "array_append", "stype": "anycompatiblearray", "initcond": "'{}'"},
+		Statement: "CREATE AGGREGATE array_accum(anycompatible) ( SFUNC = array_append, STYPE = anycompatiblearray, INITCOND = '{}' );",
+	}
+
+	tests := []struct {
+		name               string
+		input              []string
+		expectedAggregates map[string]*Aggregate
+		expectedLines      []string
+		expectedError      error
+	}{
+		{
+			name:               "No input",
+			input:              []string{},
+			expectedAggregates: map[string]*Aggregate{},
+			expectedLines:      []string{},
+			expectedError:      nil,
+		},
+		{
+			name:               "Aggregate statements with extra lines",
+			input:              []string{"abc", aggregate, "def"},
+			expectedAggregates: map[string]*Aggregate{"array_accum(anycompatible)": expectedAggregate},
+			expectedLines:      []string{"abc", "def"},
+			expectedError:      nil,
+		},
+		{
+			name:               "Missing arguments",
+			input:              []string{"CREATE AGGREGATE public.broken;"},
+			expectedAggregates: map[string]*Aggregate{},
+			expectedLines:      []string{"CREATE AGGREGATE public.broken;"},
+			expectedError:      fmt.Errorf("storing aggregates - missing aggregate arguments"),
+		},
+		{
+			name: "Same-named aggregates in different schemas",
+			input: []string{
+				"CREATE AGGREGATE public.total(integer) ( SFUNC = int4pl, STYPE = integer );",
+				"CREATE AGGREGATE reporting.total(integer) ( SFUNC = int4pl, STYPE = integer );",
+			},
+			expectedAggregates: map[string]*Aggregate{
+				"total(integer)": {
+					Name:      "total",
+					Schema:    "public",
+					Arguments: []string{"integer"},
+					Options:   map[string]string{"sfunc": "int4pl", "stype": "integer"},
+					Statement: "CREATE AGGREGATE total(integer) ( SFUNC = int4pl, STYPE = integer );",
+				},
+				"reporting.total(integer)": {
+					Name:      "total",
+					Schema:    "reporting",
+					Arguments: []string{"integer"},
+					Options:   map[string]string{"sfunc": "int4pl", "stype": "integer"},
+					Statement: "CREATE AGGREGATE reporting.total(integer) ( SFUNC = int4pl, STYPE = integer );",
+				},
+			},
+			expectedLines: []string{},
+			expectedError: nil,
+		},
+		{
+			name:               "Duplicate aggregate",
+			input:              []string{aggregate, aggregate},
+			expectedAggregates: map[string]*Aggregate{"array_accum(anycompatible)": expectedAggregate},
+			expectedLines:      []string{aggregate, aggregate},
+			expectedError:      fmt.Errorf("storing aggregates - duplicate aggregate array_accum(anycompatible)"),
+		},
+	}
+	for _, test := range tests {
+		lines, aggregates, err := StoreAggregates(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(aggregates, test.expectedAggregates) {
+			t.Error(test.name + " - aggregates error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+
+	if functions := expectedAggregate.Functions(); !cmp.Equal(functions, []string{"array_append"}) {
+		t.Error("Aggregate functions error")
+	}
+}
+
+func TestStoreOperators(t *testing.T) {
+	operator := "CREATE OPERATOR public.=== ( FUNCTION = public.loose_eq, LEFTARG = integer, RIGHTARG = integer );"
+	legacyOperator := "CREATE OPERATOR public.! ( PROCEDURE = public.factorial, LEFTARG = integer );"
+	expectedOperator := &Operator{
+		Name:      "===",
+		Schema:    "public",
+		Options:   map[string]string{"function": "public.loose_eq", "leftarg": "integer", "rightarg": "integer"},
+		Statement: "CREATE OPERATOR === ( FUNCTION = loose_eq, LEFTARG = integer, RIGHTARG = integer );",
+	}
+	expectedLegacyOperator := &Operator{
+		Name:      "!",
+		Schema:    "public",
+		Options:   map[string]string{"procedure": "public.factorial", "leftarg": "integer"},
+		Statement: "CREATE OPERATOR ! ( PROCEDURE = factorial, LEFTARG = integer );",
+	}
+
+	tests := []struct {
+		name              string
+		input             []string
+		expectedOperators map[string]*Operator
+		expectedLines     []string
+		expectedError     error
+	}{
+		{
+			name:              "No input",
+			input:             []string{},
+			expectedOperators: map[string]*Operator{},
+			expectedLines:     []string{},
+			expectedError:     nil,
+		},
+		{
+			name:  "Operator statements with extra lines",
+			input: []string{"abc", operator, legacyOperator, "CREATE OPERATOR CLASS public.c FOR TYPE integer USING btree AS STORAGE integer;"},
+			expectedOperators: map[string]*Operator{
+				"===(integer, integer)": expectedOperator,
+				"!(integer, NONE)":      expectedLegacyOperator,
+			},
+			expectedLines: []string{"abc", "CREATE OPERATOR CLASS public.c FOR TYPE integer USING btree AS STORAGE integer;"},
+			expectedError: nil,
+		},
+		{
+			name:              "Missing function",
+			input:             []string{"CREATE OPERATOR public.=== ( LEFTARG = integer );"},
+			expectedOperators: map[string]*Operator{},
+			expectedLines:     []string{"CREATE OPERATOR public.=== ( LEFTARG = integer );"},
+			expectedError:     fmt.Errorf("storing operators - missing operator function"),
+		},
+		{
+			name:  "Same-named operators in different schemas",
+			input: []string{operator, "CREATE OPERATOR reporting.=== ( FUNCTION = reporting.loose_eq, LEFTARG = integer, RIGHTARG = integer );"},
+			expectedOperators: map[string]*Operator{
+				"===(integer, integer)": expectedOperator,
+				"reporting.===(integer, integer)": {
+					Name:      "===",
+					Schema:    "reporting",
+					Options:   map[string]string{"function": "reporting.loose_eq", "leftarg": "integer", "rightarg": "integer"},
+					Statement: "CREATE OPERATOR reporting.=== ( FUNCTION = reporting.loose_eq, LEFTARG = integer, RIGHTARG = integer );",
+				},
+			},
+			expectedLines: []string{},
+			expectedError: nil,
+		},
+		{
+			name:              "Duplicate operator",
+			input:             []string{operator, operator},
+			expectedOperators: map[string]*Operator{"===(integer, integer)": expectedOperator},
+			expectedLines:     []string{operator, operator},
+			expectedError:     fmt.Errorf("storing operators - duplicate operator ===(integer, integer)"),
+		},
+	}
+	for _, test := range tests {
+		lines, operators, err := StoreOperators(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(operators, test.expectedOperators) {
+			t.Error(test.name + " - operators error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
+
+func TestStoreCasts(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         []string
+		expectedCasts map[string]*Cast
+		expectedLines []string
+		expectedError error
+	}{
+		{
+			name:          "No input",
+			input:         []string{},
+			expectedCasts: map[string]*Cast{},
+			expectedLines: []string{},
+			expectedError: nil,
+		},
+		{
+			name: "Cast statements with extra lines",
+			input: []string{
+				"abc",
+				"CREATE CAST (text AS public.email) WITH FUNCTION public.to_email(text) AS IMPLICIT;",
+				"CREATE CAST (public.email AS text) WITHOUT FUNCTION;",
+			},
+			expectedCasts: map[string]*Cast{
+				"(text AS email)": {
+					Source:    "text",
+					Target:    "email",
+					Function:  "to_email",
+					Context:   "IMPLICIT",
+					Statement: "CREATE CAST (text AS email) WITH FUNCTION to_email(text) AS IMPLICIT;",
+				},
+				"(email AS text)": {
+					Source:    "email",
+					Target:    "text",
+					Statement: "CREATE CAST (email AS text) WITHOUT FUNCTION;",
+				},
+			},
+			expectedLines: []string{"abc"},
+			expectedError: nil,
+		},
+		{
+			name:          "Missing types",
+			input:         []string{"CREATE CAST (text) WITHOUT FUNCTION;"},
+			expectedCasts: map[string]*Cast{},
+			expectedLines: []string{"CREATE CAST (text) WITHOUT FUNCTION;"},
+			expectedError: fmt.Errorf("storing casts - missing cast types"),
+		},
+		{
+			name: "Duplicate cast",
+			input: []string{
+				"CREATE CAST (public.email AS text) WITHOUT FUNCTION;",
+				"CREATE CAST (public.email AS text) WITHOUT FUNCTION;",
+			},
+			expectedCasts: map[string]*Cast{
+				"(email AS text)": {
+					Source:    "email",
+					Target:    "text",
+					Statement: "CREATE CAST (email AS text) WITHOUT FUNCTION;",
+				},
+			},
+			expectedLines: []string{
+				"CREATE CAST (public.email AS text) WITHOUT FUNCTION;",
+				"CREATE CAST (public.email AS text) WITHOUT FUNCTION;",
+			},
+			expectedError: fmt.Errorf("storing casts - duplicate cast (email AS text)"),
+		},
+	}
+	for _, test := range tests {
+		lines, casts, err := StoreCasts(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(casts, test.expectedCasts) {
+			t.Error(test.name + " - casts error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
+
+func TestStoreCollations(t *testing.T) {
+	tests := []struct {
+		name               string
+		input              []string
+		expectedCollations map[string]*Collation
+		expectedLines      []string
+		expectedError      error
+	}{
+		{
+			name:               "No input",
+			input:              []string{},
+			expectedCollations: map[string]*Collation{},
+			expectedLines:      []string{},
+		},
+		{
+			name:  "Collation statements with extra lines",
+			input: []string{"abc", "CREATE COLLATION public.german (provider = icu, locale = 'de-u-co-phonebk');", "def"},
+			expectedCollations: map[string]*Collation{
+				"german": {
+					Name:      "german",
+					Schema:    "public",
+					Options:   map[string]string{"provider": "icu", "locale": "'de-u-co-phonebk'"},
+					Statement: "CREATE COLLATION german (provider = icu, locale = 'de-u-co-phonebk');",
+				},
+			},
+			expectedLines: []string{"abc", "def"},
+		},
+		{
+			name: "Same-named collations in different schemas",
+			input: []string{
+				"CREATE COLLATION public.german (provider = icu, locale = 'de');",
+				"CREATE COLLATION reporting.german (provider = icu, locale = 'de');",
+			},
+			expectedCollations: map[string]*Collation{
+				"german": {
+					Name:      "german",
+					Schema:    "public",
+					Options:   map[string]string{"provider": "icu", "locale": "'de'"},
+					Statement: "CREATE COLLATION german (provider = icu, locale = 'de');",
+				},
+				"reporting.german": {
+					Name:      "german",
+					Schema:    "reporting",
+					Options:   map[string]string{"provider": "icu", "locale": "'de'"},
+					Statement: "CREATE COLLATION reporting.german (provider = icu, locale = 'de');",
+				},
+			},
+			expectedLines: []string{},
+		},
+		{
+			name: "Duplicate collation",
+			input: []string{
+				"CREATE COLLATION reporting.german (provider = icu, locale = 'de');",
+				"CREATE COLLATION reporting.german (provider = icu, locale = 'de');",
+			},
+			expectedCollations: map[string]*Collation{
+				"reporting.german": {
+					Name:      "german",
+					Schema:    "reporting",
+					Options:   map[string]string{"provider": "icu", "locale": "'de'"},
+					Statement: "CREATE COLLATION reporting.german (provider = icu, locale = 'de');",
+				},
+			},
+			expectedLines: []string{
+				"CREATE COLLATION reporting.german (provider = icu, locale = 'de');",
+				"CREATE COLLATION reporting.german (provider = icu, locale = 'de');",
+			},
+			expectedError: fmt.Errorf("storing collations - duplicate collation reporting.german"),
+		},
+	}
+	for _, test := range tests {
+		lines, collations, err := StoreCollations(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(collations, test.expectedCollations) {
+			t.Error(test.name + " - collations error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package parse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severities of schema changes for backward compatibility, from least to most severe
+const (
+	SeveritySafe     = "safe"
+	SeverityRisky    = "risky"
+	SeverityBreaking = "breaking"
+)
+
+// Severities are the severities of schema changes in increasing order
+var Severities = []string{SeveritySafe, SeverityRisky, SeverityBreaking}
+
+// Finding is a change between schemas classified by how it affects clients of the old schema and the database while
+// it is applied
+type Finding struct {
+	*Change
+	Severity string `json:"severity"`
+	Reason   string `json:"reason"`
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s %s (%s)", f.Severity, f.Change, f.Reason)
+}
+
+// severityRank returns the position of the severity among the severities
+func severityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// Exceeds returns whether the finding is more severe than the threshold severity
+func (f *Finding) Exceeds(threshold string) bool {
+	return severityRank(f.Severity) > severityRank(threshold)
+}
+
+// typeSizes maps numeric types to their families and sizes in bytes
+var typeSizes = map[string][2]int{
+	"smallint":         {0, 2},
+	"integer":          {0, 4},
+	"bigint":           {0, 8},
+	"real":             {1, 4},
+	"double precision": {1, 8},
+}
+
+// typeModifiers returns the numbers of the type modifier of a type
+func typeModifiers(modifier string) []int {
+	var modifiers []int
+	for _, part := range splitTopLevel(strings.Trim(modifier, "()"), ',') {
+		if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			modifiers = append(modifiers, n)
+		}
+	}
+	return modifiers
+}
+
+// typeChange classifies a change of column type. Widening a numeric type rewrites the table, while lifting or
+// raising the length limit of a varying type or the precision of a numeric type does not. Other changes may reject
+// or alter existing values or the values clients read.
+func typeChange(from, to string) (string, string) {
+	fromMatches := columnTypeExp.FindStringSubmatch(normaliseType(from, TypeStyleStandard))
+	toMatches := columnTypeExp.FindStringSubmatch(normaliseType(to, TypeStyleStandard))
+	if fromMatches == nil || toMatches == nil || fromMatches[5] != toMatches[5] {
+		return SeverityBreaking, "changes the type read and written by clients"
+	}
+	fromName := strings.TrimPrefix(fromMatches[1], "pg_catalog.")
+	toName := strings.TrimPrefix(toMatches[1], "pg_catalog.")
+	fromModifiers, toModifiers := typeModifiers(fromMatches[2]), typeModifiers(toMatches[2])
+
+	fromSize, fromSized := typeSizes[fromName]
+	toSize, toSized := typeSizes[toName]
+	switch {
+	case fromSized && toSized && fromSize[0] == toSize[0] && toSize[1] > fromSize[1]:
+		return SeverityRisky, "widens the type, rewriting the table under an exclusive lock"
+	case fromSized && toSized && fromSize[0] == toSize[0]:
+		return SeverityBreaking, "narrows the type, rejecting values out of its range"
+	case fromName == "character varying" && toName == "text":
+		return SeveritySafe, "lifts the length limit without rewriting the table"
+	case fromName != toName:
+		return SeverityBreaking, "changes the type read and written by clients"
+	case len(toModifiers) == 0:
+		return SeveritySafe, "lifts the type modifier without rewriting the table"
+	case len(fromModifiers) == 0 || toModifiers[0] < fromModifiers[0]:
+		return SeverityBreaking, "narrows the type, rejecting or truncating existing values"
+	case fromName == "numeric" && (len(fromModifiers) != len(toModifiers) ||
+		len(toModifiers) > 1 && toModifiers[1] != fromModifiers[1]):
+		return SeverityRisky, "changes the scale, rewriting the table and rounding existing values"
+	case fromName == "character varying" || fromName == "bit varying" || fromName == "numeric":
+		return SeveritySafe, "raises the type modifier without rewriting the table"
+	}
+	return SeverityRisky, "changes the type modifier, rewriting the table under an exclusive lock"
+}
+
+// classifyColumn classifies a change of a column
+func classifyColumn(change *Change, renamed string) (string, string) {
+	switch change.Action {
+	case ActionAdded:
+		_, def, notNull, clauses := columnParts(change.New)
+		if strings.Contains(clauses, "GENERATED ") {
+			return SeverityRisky, "fills the generated column of existing rows, rewriting the table under an exclusive " +
+				"lock"
+		}
+		if notNull && len(def) == 0 {
+			return SeverityBreaking, "adds a not null column without a default, failing for existing rows and " +
+				"inserts without it"
+		}
+		return SeveritySafe, "adds a column"
+	case ActionRemoved:
+		if len(renamed) > 0 {
+			return SeverityBreaking, "renames the column to " + renamed + ", breaking clients using the old name"
+		}
+		return SeverityBreaking, "drops the column and its data, breaking clients using it"
+	}
+
+	switch change.Attribute {
+	case "type":
+		return typeChange(change.Old, change.New)
+	case "default":
+		if len(change.New) == 0 {
+			return SeverityRisky, "drops the default, leaving inserts relying on it without a value"
+		}
+		return SeveritySafe, "changes the default of new rows"
+	case "nullability":
+		if change.New == "NOT NULL" {
+			return SeverityRisky, "scans the table under an exclusive lock and fails if it holds nulls"
+		}
+		return SeveritySafe, "allows nulls"
+	case "definition":
+		return SeverityRisky, "changes the column definition, which may rewrite the table"
+	}
+	return SeveritySafe, "changes a column attribute"
+}
+
+// classifyConstraint classifies a change of a constraint. Constraints which cannot be parsed are classified as risky.
+func classifyConstraint(change *Change) (string, string) {
+	if change.Action == ActionRemoved {
+		constraint, err := parseConstraint(change.Old)
+		if err != nil {
+			return SeverityRisky, "drops a constraint which may be relied on"
+		} else if constraint.Kind == CheckConstraint || constraint.Kind == ForeignKeyConstraint {
+			return SeveritySafe, "drops a constraint"
+		}
+		return SeverityRisky, "drops a constraint, failing upserts relying on it and foreign keys referencing it"
+	}
+
+	constraint, err := parseConstraint(change.New)
+	switch {
+	case err != nil:
+		return SeverityRisky, "adds a constraint which may lock the table or fail on existing rows"
+	case constraint.NotValid:
+		return SeveritySafe, "adds a constraint checked for new rows only"
+	case constraint.Kind == CheckConstraint || constraint.Kind == ForeignKeyConstraint:
+		return SeverityRisky, "validates existing rows under a lock, failing if any violates it, unless added NOT " +
+			"VALID"
+	}
+	return SeverityRisky, "builds an index under an exclusive lock, failing if existing rows violate it"
+}
+
+// classify classifies a change between schemas. Added objects are safe, removed objects breaking and changed objects
+// risky unless their kind is classified otherwise.
+func classify(change *Change, renamed string) (string, string) {
+	switch change.Kind {
+	case KindColumn:
+		return classifyColumn(change, renamed)
+	case KindConstraint:
+		return classifyConstraint(change)
+	case KindIndex:
+		if change.Action == ActionRemoved && !strings.HasPrefix(change.Old, "CREATE UNIQUE ") {
+			return SeveritySafe, "drops an index, which may slow queries using it"
+		} else if change.Action == ActionRemoved {
+			return SeverityRisky, "drops a unique index, failing upserts relying on it"
+		}
+		return SeverityRisky, "blocks writes while building the index unless it is created concurrently"
+	case KindTable:
+		switch change.Action {
+		case ActionRemoved:
+			return SeverityBreaking, "drops the table and its data, breaking clients using it"
+		case ActionModified:
+			if change.Attribute == "clauses" {
+				return SeverityRisky, "changes the table clauses, which may rewrite or repartition the table"
+			}
+			return SeveritySafe, "changes a table attribute"
+		}
+	case KindStatistics:
+		return SeveritySafe, "changes planner statistics"
+	case KindTrigger, KindRule, KindEventTrigger:
+		return SeverityRisky, "changes the behaviour of statements"
+	}
+
+	switch change.Action {
+	case ActionAdded:
+		return SeveritySafe, "adds an object"
+	case ActionRemoved:
+		return SeverityBreaking, "drops an object, breaking clients and objects using it"
+	}
+	if change.Attribute == "body" || change.Attribute == "query" {
+		return SeverityRisky, "changes the " + change.Attribute + ", which may change the results clients read"
+	}
+	return SeverityRisky, "changes the definition"
+}
+
+// CheckSchemas returns the changes from one schema to another classified as safe, risky or breaking changes for
+// zero-downtime deployment. Breaking changes, such as dropping or renaming columns and narrowing types, break clients
+// of the old schema, while risky changes, such as adding not null and check constraints or indexes, may lock or
+// rewrite tables or fail on existing data. Removed columns are reported as renamed if an added column of the same
+// table has the same definition.
+func CheckSchemas(from, to *Schema) []*Finding {
+	changes := DiffSchemas(from, to)
+	added := make(map[string][]string)
+	for _, change := range changes {
+		if change.Kind == KindColumn && change.Action == ActionAdded {
+			added[change.New] = append(added[change.New], change.Object)
+		}
+	}
+
+	findings := make([]*Finding, len(changes))
+	for i, change := range changes {
+		renamed := ""
+		if change.Kind == KindColumn && change.Action == ActionRemoved {
+			table := change.Object[:strings.LastIndex(change.Object, ".")+1]
+			for _, column := range added[change.Old] {
+				if strings.HasPrefix(column, table) {
+					renamed = column
+					break
+				}
+			}
+		}
+		severity, reason := classify(change, renamed)
+		findings[i] = &Finding{Change: change, Severity: severity, Reason: reason}
+	}
+	return findings
+}
@@ -0,0 +1,109 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTypeChange(t *testing.T) {
+	tests := []struct {
+		name             string
+		from             string
+		to               string
+		expectedSeverity string
+	}{
+		{name: "Widened integer", from: "integer", to: "int8", expectedSeverity: SeverityRisky},
+		{name: "Narrowed integer", from: "bigint", to: "integer", expectedSeverity: SeverityBreaking},
+		{name: "Raised length limit", from: "varchar(20)", to: "character varying(40)", expectedSeverity: SeveritySafe},
+		{name: "Lowered length limit", from: "character varying(40)", to: "character varying(20)",
+			expectedSeverity: SeverityBreaking},
+		{name: "Lifted length limit", from: "character varying(40)", to: "text", expectedSeverity: SeveritySafe},
+		{name: "Added length limit", from: "text", to: "character varying(40)", expectedSeverity: SeverityBreaking},
+		{name: "Changed scale", from: "numeric(10,2)", to: "numeric(12,4)", expectedSeverity: SeverityRisky},
+		{name: "Raised precision", from: "numeric(10,2)", to: "numeric(12,2)", expectedSeverity: SeveritySafe},
+		{name: "Raised character length", from: "character(2)", to: "character(4)", expectedSeverity: SeverityRisky},
+		{name: "Changed array dimensions", from: "integer", to: "integer[]", expectedSeverity: SeverityBreaking},
+		{name: "Changed type", from: "integer", to: "text", expectedSeverity: SeverityBreaking},
+	}
+	for _, test := range tests {
+		if severity, _ := typeChange(test.from, test.to); severity != test.expectedSeverity {
+			t.Error(test.name + " - severity error")
+		}
+	}
+}
+
+func TestClassifyConstraint(t *testing.T) {
+	tests := []struct {
+		name             string
+		change           *Change
+		expectedSeverity string
+	}{
+		{
+			name:             "Dropped check",
+			change:           &Change{Action: ActionRemoved, Old: "CONSTRAINT orders_total_check CHECK ((total > 0))"},
+			expectedSeverity: SeveritySafe,
+		},
+		{
+			name:             "Dropped unique",
+			change:           &Change{Action: ActionRemoved, Old: "CONSTRAINT orders_code_key UNIQUE (code)"},
+			expectedSeverity: SeverityRisky,
+		},
+		{
+			name:             "Dropped unparseable constraint",
+			change:           &Change{Action: ActionRemoved, Old: "CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id)"},
+			expectedSeverity: SeverityRisky,
+		},
+		{
+			name:             "Added unparseable constraint",
+			change:           &Change{Action: ActionAdded, New: "CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) NOT VALID"},
+			expectedSeverity: SeverityRisky,
+		},
+	}
+	for _, test := range tests {
+		if severity, _ := classifyConstraint(test.change); severity != test.expectedSeverity {
+			t.Error(test.name + " - severity error")
+		}
+	}
+}
+
+func TestCheckSchemas(t *testing.T) {
+	fromDump := "CREATE TABLE public.orders (\n    id integer NOT NULL,\n    note text,\n    total numeric\n);\n\n" +
+		"CREATE TABLE public.tags (\n    id integer NOT NULL\n);\n\n" +
+		"CREATE INDEX orders_total_idx ON public.orders USING btree (total);\n"
+	toDump := "CREATE TABLE public.orders (\n    id bigint NOT NULL,\n    comment text,\n" +
+		"    total numeric NOT NULL,\n    code text NOT NULL\n);\n\n" +
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_total_check CHECK ((total > 0)) NOT VALID;\n\n" +
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_code_key UNIQUE (code);\n"
+	from, to := readTestSchema(t, fromDump), readTestSchema(t, toDump)
+
+	expectedFindings := []string{
+		"breaking - table tags (drops the table and its data, breaking clients using it)",
+		"breaking - column orders.note (renames the column to orders.comment, breaking clients using the old name)",
+		"breaking + column orders.code (adds a not null column without a default, failing for existing rows and " +
+			"inserts without it)",
+		"safe + column orders.comment (adds a column)",
+		"risky ~ column orders.id type: integer -> bigint (widens the type, rewriting the table under an exclusive " +
+			"lock)",
+		"risky ~ column orders.total nullability: NULL -> NOT NULL (scans the table under an exclusive lock and " +
+			"fails if it holds nulls)",
+		"risky + constraint orders_code_key on orders (builds an index under an exclusive lock, failing if existing " +
+			"rows violate it)",
+		"safe + constraint orders_total_check on orders (adds a constraint checked for new rows only)",
+		"safe - index orders_total_idx on orders (drops an index, which may slow queries using it)",
+	}
+	var findings []string
+	exceeding := 0
+	for _, finding := range CheckSchemas(from, to) {
+		findings = append(findings, finding.String())
+		if finding.Exceeds(SeverityRisky) {
+			exceeding++
+		}
+	}
+	if !cmp.Equal(findings, expectedFindings) {
+		t.Error("Check schemas - findings error")
+	}
+	if exceeding != 3 {
+		t.Error("Check schemas - threshold error")
+	}
+}
@@ -0,0 +1,127 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Type styles columns types can be normalised to
+const (
+	TypeStyleStandard = "standard"
+	TypeStyleShort    = "short"
+)
+
+// columnTypeExp splits a type into its name, type modifier, time zone qualifier and array dimensions
+var columnTypeExp = regexp.MustCompile(`^([^(\[]+?)\s*(\([0-9, ]+\))?( with(out)? time zone)?((\[[0-9]*\])*)$`)
+
+// columnClauseKeywords are the keywords ending the type of a column definition
+var columnClauseKeywords = map[string]bool{
+	"NOT":        true,
+	"NULL":       true,
+	"DEFAULT":    true,
+	"COLLATE":    true,
+	"CONSTRAINT": true,
+	"GENERATED":  true,
+	"CHECK":      true,
+	"REFERENCES": true,
+	"PRIMARY":    true,
+	"UNIQUE":     true,
+}
+
+// standardTypeNames maps type aliases to their SQL standard names
+var standardTypeNames = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"int2":        "smallint",
+	"int8":        "bigint",
+	"float4":      "real",
+	"float8":      "double precision",
+	"decimal":     "numeric",
+	"bool":        "boolean",
+	"varchar":     "character varying",
+	"char":        "character",
+	"varbit":      "bit varying",
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"time":        "time without time zone",
+	"timetz":      "time with time zone",
+}
+
+// shortTypeNames maps SQL standard type names to their short aliases
+var shortTypeNames = map[string]string{
+	"integer":                     "int4",
+	"smallint":                    "int2",
+	"bigint":                      "int8",
+	"real":                        "float4",
+	"double precision":            "float8",
+	"boolean":                     "bool",
+	"character varying":           "varchar",
+	"character":                   "char",
+	"bit varying":                 "varbit",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"time without time zone":      "time",
+	"time with time zone":         "timetz",
+}
+
+// splitColumnStatement splits a column statement into its type and the rest of its definition
+func splitColumnStatement(stmt string) (string, string) {
+	tokens := tokenize(stmt)
+	n := 0
+	for n < len(tokens) && !columnClauseKeywords[tokens[n]] {
+		n++
+	}
+	typ := strings.Join(tokens[:n], " ")
+	return typ, strings.Join(tokens[n:], " ")
+}
+
+// normaliseType returns typ using the type names of style. Types which are not built in are returned unchanged.
+func normaliseType(typ, style string) string {
+	matches := columnTypeExp.FindStringSubmatch(typ)
+	if matches == nil || style != TypeStyleStandard && style != TypeStyleShort {
+		return typ
+	}
+	name, modifier, zone, arrays := strings.TrimPrefix(matches[1], "pg_catalog."), matches[2], matches[3], matches[5]
+
+	// the standard name includes the time zone qualifier, which follows the type modifier
+	if standard, ok := standardTypeNames[name]; ok && len(zone) == 0 {
+		name = standard
+	} else if name == "bpchar" && len(modifier) > 0 {
+		// bpchar without a length is unbounded unlike character, which defaults to a length of 1
+		name = "character"
+	} else if name == "timestamp" || name == "time" {
+		name += zone
+	}
+	if _, ok := shortTypeNames[name]; !ok && name != "numeric" {
+		return typ
+	}
+
+	if style == TypeStyleShort {
+		if short, ok := shortTypeNames[name]; ok {
+			name = short
+		}
+		return name + modifier + arrays
+	}
+	if index := strings.Index(name, " with"); index != -1 {
+		return name[:index] + modifier + name[index:] + arrays
+	}
+	return name + modifier + arrays
+}
+
+// Type returns the data type of the column
+func (c *Column) Type() string {
+	typ, _ := splitColumnStatement(c.Statement)
+	return typ
+}
+
+// NormalisedStatement returns the statement of the column with its type using the type names of style
+func (c *Column) NormalisedStatement(style string) string {
+	if len(style) == 0 {
+		return c.Statement
+	}
+	typ, rest := splitColumnStatement(c.Statement)
+	if len(rest) == 0 {
+		return normaliseType(typ, style)
+	}
+	return normaliseType(typ, style) + " " + rest
+}
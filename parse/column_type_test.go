@@ -0,0 +1,83 @@
+package parse
+
+import (
+	"testing"
+)
+
+func TestNormaliseType(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		expectedStandard string
+		expectedShort    string
+	}{
+		{
+			name:             "Unknown type",
+			input:            "public.email",
+			expectedStandard: "public.email",
+			expectedShort:    "public.email",
+		},
+		{
+			name:             "Character varying",
+			input:            "character varying(255)",
+			expectedStandard: "character varying(255)",
+			expectedShort:    "varchar(255)",
+		},
+		{
+			name:             "Short alias",
+			input:            "int4",
+			expectedStandard: "integer",
+			expectedShort:    "int4",
+		},
+		{
+			name:             "Timestamp with precision",
+			input:            "timestamp(3) without time zone",
+			expectedStandard: "timestamp(3) without time zone",
+			expectedShort:    "timestamp(3)",
+		},
+		{
+			name:             "Timestamp with time zone alias",
+			input:            "pg_catalog.timestamptz",
+			expectedStandard: "timestamp with time zone",
+			expectedShort:    "timestamptz",
+		},
+		{
+			name:             "Blank-padded character with length",
+			input:            "bpchar(3)",
+			expectedStandard: "character(3)",
+			expectedShort:    "char(3)",
+		},
+		{
+			name:             "Blank-padded character without length",
+			input:            "bpchar",
+			expectedStandard: "bpchar",
+			expectedShort:    "bpchar",
+		},
+		{
+			name:             "Array of decimals",
+			input:            "decimal(10,2)[]",
+			expectedStandard: "numeric(10,2)[]",
+			expectedShort:    "numeric(10,2)[]",
+		},
+	}
+	for _, test := range tests {
+		if normaliseType(test.input, TypeStyleStandard) != test.expectedStandard {
+			t.Error(test.name + " - standard type error")
+		} else if normaliseType(test.input, TypeStyleShort) != test.expectedShort {
+			t.Error(test.name + " - short type error")
+		}
+	}
+}
+
+func TestColumnNormalisedStatement(t *testing.T) {
+	column := &Column{Statement: "character varying(64) COLLATE pg_catalog.\"C\" NOT NULL"}
+	if column.Type() != "character varying(64)" {
+		t.Error("Column type error")
+	}
+	if column.NormalisedStatement("") != column.Statement {
+		t.Error("Column statement error")
+	}
+	if column.NormalisedStatement(TypeStyleShort) != "varchar(64) COLLATE pg_catalog.\"C\" NOT NULL" {
+		t.Error("Column short statement error")
+	}
+}
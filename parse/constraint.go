@@ -0,0 +1,181 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is the struct containing logical aspects of a table constraint
+type Constraint struct {
+	Name              string
+	Kind              string
+	Columns           []string
+	Include           []string
+	Expression        string
+	Method            string
+	Where             string
+	NullsNotDistinct  bool
+	RefTable          string
+	RefColumns        []string
+	Match             string
+	OnDelete          string
+	OnUpdate          string
+	Deferrable        bool
+	InitiallyDeferred bool
+	NotValid          bool
+	NoInherit         bool
+	Statement         string
+}
+
+// Constraint kinds
+const (
+	PrimaryKeyConstraint = "PRIMARY KEY"
+	ForeignKeyConstraint = "FOREIGN KEY"
+	UniqueConstraint     = "UNIQUE"
+	CheckConstraint      = "CHECK"
+	ExcludeConstraint    = "EXCLUDE"
+)
+
+// unwrap returns s without its enclosing parentheses, if any
+func unwrap(s string) string {
+	if strings.HasPrefix(s, "(") && matchingParen(s, 0) == len(s)-1 {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// parenList returns the elements of the parenthesised list s
+func parenList(s string) []string {
+	return splitTopLevel(unwrap(s), ',')
+}
+
+// referentialAction returns the referential action starting at index i of tokens and the number of tokens it spans
+func referentialAction(tokens []string, i int) (string, int) {
+	if i >= len(tokens) {
+		return "", 0
+	}
+	action, n := tokens[i], 1
+	if (action == "SET" || action == "NO") && i+1 < len(tokens) {
+		action += " " + tokens[i+1]
+		n++
+		// SET NULL and SET DEFAULT may be restricted to a column list
+		if i+2 < len(tokens) && strings.HasPrefix(tokens[i+2], "(") {
+			action += " " + tokens[i+2]
+			n++
+		}
+	}
+	return action, n
+}
+
+// parseConstraint parses a "CONSTRAINT name ..." definition into a Constraint. The returned constraint always holds
+// the name and statement of the definition, even if the rest of it cannot be parsed.
+func parseConstraint(def string) (*Constraint, error) {
+	tokens := tokenize(def)
+	constraint := &Constraint{Statement: def}
+	if len(tokens) < 3 || tokens[0] != "CONSTRAINT" {
+		return constraint, fmt.Errorf("invalid constraint")
+	}
+	constraint.Name = tokens[1]
+
+	i := 2
+	switch tokens[i] {
+	case "PRIMARY", "FOREIGN":
+		if i+1 >= len(tokens) || tokens[i+1] != "KEY" {
+			return constraint, fmt.Errorf("invalid constraint")
+		}
+		constraint.Kind = tokens[i] + " KEY"
+		i += 2
+	case UniqueConstraint, CheckConstraint, ExcludeConstraint:
+		constraint.Kind = tokens[i]
+		i++
+	default:
+		return constraint, fmt.Errorf("invalid constraint")
+	}
+
+	if constraint.Kind == UniqueConstraint && i+2 < len(tokens) && tokens[i] == "NULLS" && tokens[i+1] == "NOT" &&
+		tokens[i+2] == "DISTINCT" {
+		constraint.NullsNotDistinct = true
+		i += 3
+	} else if constraint.Kind == ExcludeConstraint && i+1 < len(tokens) && tokens[i] == "USING" {
+		constraint.Method = tokens[i+1]
+		i += 2
+	}
+	if i >= len(tokens) || !strings.HasPrefix(tokens[i], "(") {
+		return constraint, fmt.Errorf("invalid constraint")
+	}
+
+	switch constraint.Kind {
+	case CheckConstraint:
+		constraint.Expression = unwrap(tokens[i])
+	case ExcludeConstraint:
+		constraint.Expression = unwrap(tokens[i])
+		for _, element := range parenList(tokens[i]) {
+			if column := strings.Split(element, " ")[0]; isIdentifier(column) {
+				constraint.Columns = append(constraint.Columns, column)
+			}
+		}
+	default:
+		constraint.Columns = parenList(tokens[i])
+	}
+	i++
+
+	if constraint.Kind == ForeignKeyConstraint {
+		if i >= len(tokens) || tokens[i] != "REFERENCES" || i+1 >= len(tokens) {
+			return constraint, fmt.Errorf("invalid constraint")
+		}
+		ref := tokens[i+1]
+		i += 2
+		if index := strings.Index(ref, "("); index != -1 {
+			constraint.RefColumns = parenList(ref[index:])
+			ref = ref[:index]
+		} else if i < len(tokens) && strings.HasPrefix(tokens[i], "(") {
+			constraint.RefColumns = parenList(tokens[i])
+			i++
+		}
+		constraint.RefTable, _ = removeAccessModifier(ref)
+	}
+
+	for ; i < len(tokens); i++ {
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch tokens[i] {
+		case "INCLUDE":
+			constraint.Include = parenList(next)
+			i++
+		case "WHERE":
+			constraint.Where = unwrap(next)
+			i++
+		case "WITH", "MATCH":
+			if tokens[i] == "MATCH" {
+				constraint.Match = next
+			}
+			i++
+		case "USING":
+			// USING INDEX TABLESPACE name
+			i += 3
+		case "ON":
+			action, n := referentialAction(tokens, i+2)
+			if next == "DELETE" {
+				constraint.OnDelete = action
+			} else if next == "UPDATE" {
+				constraint.OnUpdate = action
+			}
+			i += 1 + n
+		case "DEFERRABLE":
+			constraint.Deferrable = true
+		case "INITIALLY":
+			constraint.InitiallyDeferred = next == "DEFERRED"
+			i++
+		case "NOT":
+			constraint.NotValid = constraint.NotValid || next == "VALID"
+			i++
+		case "NO":
+			constraint.NoInherit = constraint.NoInherit || next == "INHERIT"
+			i++
+		}
+	}
+
+	return constraint, nil
+}
@@ -0,0 +1,113 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		name               string
+		input              string
+		expectedConstraint *Constraint
+		expectedError      error
+	}{
+		{
+			name:               "Invalid constraint",
+			input:              "CONSTRAINT broken DEFAULT 1",
+			expectedConstraint: &Constraint{Name: "broken", Statement: "CONSTRAINT broken DEFAULT 1"},
+			expectedError:      fmt.Errorf("invalid constraint"),
+		},
+		{
+			name:  "Check constraint with nested parentheses",
+			input: "CONSTRAINT amount_check CHECK (((amount > (0)::numeric) AND (amount < (100)::numeric))) NOT VALID",
+			expectedConstraint: &Constraint{
+				Name:       "amount_check",
+				Kind:       CheckConstraint,
+				Expression: "((amount > (0)::numeric) AND (amount < (100)::numeric))",
+				NotValid:   true,
+				Statement:  "CONSTRAINT amount_check CHECK (((amount > (0)::numeric) AND (amount < (100)::numeric))) NOT VALID",
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "Exclude constraint",
+			input: "CONSTRAINT no_overlap EXCLUDE USING gist (room WITH =, tsrange(starts, ends) WITH &&) WHERE ((cancelled IS FALSE))",
+			expectedConstraint: &Constraint{
+				Name:       "no_overlap",
+				Kind:       ExcludeConstraint,
+				Columns:    []string{"room"},
+				Expression: "room WITH =, tsrange(starts, ends) WITH &&",
+				Method:     "gist",
+				Where:      "(cancelled IS FALSE)",
+				Statement:  "CONSTRAINT no_overlap EXCLUDE USING gist (room WITH =, tsrange(starts, ends) WITH &&) WHERE ((cancelled IS FALSE))",
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "Unique constraint with included columns",
+			input: "CONSTRAINT email_key UNIQUE NULLS NOT DISTINCT (email) INCLUDE (name, created_at) WITH (fillfactor='90')",
+			expectedConstraint: &Constraint{
+				Name:             "email_key",
+				Kind:             UniqueConstraint,
+				Columns:          []string{"email"},
+				Include:          []string{"name", "created_at"},
+				NullsNotDistinct: true,
+				Statement:        "CONSTRAINT email_key UNIQUE NULLS NOT DISTINCT (email) INCLUDE (name, created_at) WITH (fillfactor='90')",
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "Composite foreign key constraint",
+			input: "CONSTRAINT line_fkey FOREIGN KEY (tenant_id, order_id) REFERENCES orders(tenant_id, id) MATCH FULL ON UPDATE CASCADE ON DELETE SET NULL (order_id) DEFERRABLE INITIALLY DEFERRED",
+			expectedConstraint: &Constraint{
+				Name:              "line_fkey",
+				Kind:              ForeignKeyConstraint,
+				Columns:           []string{"tenant_id", "order_id"},
+				RefTable:          "orders",
+				RefColumns:        []string{"tenant_id", "id"},
+				Match:             "FULL",
+				OnDelete:          "SET NULL (order_id)",
+				OnUpdate:          "CASCADE",
+				Deferrable:        true,
+				InitiallyDeferred: true,
+				Statement:         "CONSTRAINT line_fkey FOREIGN KEY (tenant_id, order_id) REFERENCES orders(tenant_id, id) MATCH FULL ON UPDATE CASCADE ON DELETE SET NULL (order_id) DEFERRABLE INITIALLY DEFERRED",
+			},
+			expectedError: nil,
+		},
+	}
+	for _, test := range tests {
+		constraint, err := parseConstraint(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(constraint, test.expectedConstraint) {
+			t.Error(test.name + " - constraint error")
+		}
+	}
+}
+
+func TestMapConstraintsUnique(t *testing.T) {
+	newTable := func() *Table {
+		return &Table{
+			Columns: map[string]*Column{
+				"email":     {Statement: "text"},
+				"tenant_id": {Statement: "integer"},
+			},
+			Constraints: make(map[string]*Constraint),
+		}
+	}
+	tables := map[string]*Table{"users": newTable()}
+	lines, err := MapConstraints([]string{
+		"ALTER TABLE ONLY public.users ADD CONSTRAINT users_email_key UNIQUE (email);",
+		"ALTER TABLE ONLY public.users ADD CONSTRAINT users_tenant_email_key UNIQUE (tenant_id, email);",
+	}, tables)
+	if err != nil || len(lines) != 0 {
+		t.Error("Unique constraints - fatal error")
+	} else if !tables["users"].Columns["email"].IsUnique || tables["users"].Columns["tenant_id"].IsUnique {
+		t.Error("Unique constraints - columns error")
+	} else if len(tables["users"].Constraints) != 2 {
+		t.Error("Unique constraints - constraints error")
+	}
+}
@@ -0,0 +1,477 @@
+package parse
+
+import (
+	"strings"
+
+	"github.com/jchiam/psql-schema-dump-sanitiser/graph"
+)
+
+// Kinds of printed objects identified in the dependency graph besides the kinds filters can be restricted to
+const (
+	KindType          = "type"
+	KindDomain        = "domain"
+	KindDictionary    = "dictionary"
+	KindConfiguration = "configuration"
+	KindOperator      = "operator"
+	KindCast          = "cast"
+	KindWrapper       = "wrapper"
+	KindServer        = "server"
+	KindUserMapping   = "user-mapping"
+	KindPublication   = "publication"
+	KindSubscription  = "subscription"
+	KindEventTrigger  = "event-trigger"
+
+	// partition index attachments and deferred foreign keys are printed together after all tables
+	kindAttachments = "attachments"
+	kindForeignKeys = "foreign-keys"
+)
+
+// objectID returns the id of an object in the dependency graph
+func objectID(kind, key string) string {
+	return kind + ":" + key
+}
+
+// objectKind returns the kind of the object with the id
+func objectKind(id string) string {
+	return id[:strings.Index(id, ":")]
+}
+
+// Ways names are referenced in sql expressions
+const (
+	referenceName = 1 << iota
+	referenceCall
+	referenceRegclass
+)
+
+// references returns the unqualified and unquoted names referenced by the sql expression, mapped to the ways they are
+// referenced. Relations referenced by regclass literals, such as sequences in nextval defaults, are included.
+// Qualified names are also included with the qualifier of their last part, such as table.column for columns.
+func references(expr string) map[string]int {
+	names := make(map[string]int)
+	for i := 0; i < len(expr); {
+		if expr[i] == '\'' || expr[i] == '$' && len(dollarTagAt(expr, i)) > 0 {
+			j := skipQuoted(expr, i)
+			if expr[i] == '\'' && strings.HasPrefix(expr[j:], "::regclass") {
+				name, _ := removeAccessModifier(expr[i+1 : j-1])
+				names[strings.Trim(name, "\"")] |= referenceRegclass
+			}
+			i = j
+			continue
+		}
+		if expr[i] >= '0' && expr[i] <= '9' {
+			// skip numbers
+			for i++; i < len(expr) && !isWordBoundary(expr, i); i++ {
+			}
+			continue
+		}
+		if expr[i] != '"' && isWordBoundary(expr, i) {
+			i++
+			continue
+		}
+
+		// read a possibly qualified name, keeping its last part and its qualifier
+		var name, qualifier string
+		for {
+			qualifier = name
+			if expr[i] == '"' {
+				j := skipQuoted(expr, i)
+				name = strings.Replace(expr[i+1:j-1], "\"\"", "\"", -1)
+				i = j
+			} else {
+				j := i
+				for j < len(expr) && (!isWordBoundary(expr, j) || expr[j] == '$') {
+					j++
+				}
+				name, i = expr[i:j], j
+			}
+			if i+1 < len(expr) && expr[i] == '.' && (expr[i+1] == '"' || !isWordBoundary(expr, i+1)) {
+				i++
+				continue
+			}
+			break
+		}
+		j := i
+		for j < len(expr) && expr[j] == ' ' {
+			j++
+		}
+		ways := referenceName
+		if j < len(expr) && expr[j] == '(' {
+			ways = referenceCall
+		}
+		names[name] |= ways
+		if len(qualifier) > 0 {
+			names[qualifier+"."+name] |= ways
+		}
+	}
+	return names
+}
+
+// triggerFunction returns the name of the function executed by the trigger statement
+func triggerFunction(stmt string) string {
+	// PostgreSQL versions before 11 use EXECUTE PROCEDURE instead of EXECUTE FUNCTION
+	index := strings.Index(stmt, "EXECUTE FUNCTION ")
+	if index == -1 {
+		index = strings.Index(stmt, "EXECUTE PROCEDURE ")
+	}
+	if index == -1 {
+		return ""
+	}
+	function := strings.Fields(stmt[index:])[2]
+	if open := strings.Index(function, "("); open != -1 {
+		function = function[:open]
+	}
+	name, _ := removeAccessModifier(function)
+	return name
+}
+
+// dependencyIndex maps the names of objects to their ids in the dependency graph
+type dependencyIndex struct {
+	relations  map[string][]string
+	functions  map[string][]string
+	types      map[string][]string
+	collations map[string][]string
+}
+
+func (d *dependencyIndex) add(index map[string][]string, name, id string) {
+	name = strings.Trim(name, "\"")
+	index[name] = append(index[name], id)
+}
+
+// addReferences adds edges from the object to the objects referenced by the sql expression. Functions are matched by
+// calls and types and collations by name. Relations are matched by regclass literals, and by name if relations is set
+// as names in expressions such as checks and defaults are mostly columns.
+func (d *dependencyIndex) addReferences(g *graph.Graph, id, expr string, relations bool) {
+	for name, ways := range references(expr) {
+		var ids []string
+		if ways&referenceCall != 0 {
+			ids = append(ids, d.functions[name]...)
+		}
+		if ways&referenceRegclass != 0 || relations && ways&referenceName != 0 {
+			ids = append(ids, d.relations[name]...)
+		}
+		if ways&referenceName != 0 {
+			ids = append(ids, d.types[name]...)
+			ids = append(ids, d.collations[name]...)
+		}
+		for _, ref := range ids {
+			if ref != id {
+				g.AddEdge(id, ref)
+			}
+		}
+	}
+}
+
+// addFunction adds edges from the object to every overload of the function, given by name and optionally by schema
+// and argument types
+func (d *dependencyIndex) addFunction(g *graph.Graph, id, function string) {
+	if index := strings.Index(function, "("); index != -1 {
+		function = function[:index]
+	}
+	name, _ := removeAccessModifier(strings.TrimSpace(function))
+	for _, ref := range d.functions[name] {
+		if ref != id {
+			g.AddEdge(id, ref)
+		}
+	}
+}
+
+// newDependencyIndex indexes the objects of the schema by name. Sequences owned by tables are indexed as their tables
+// as they are created with them.
+func newDependencyIndex(schema *Schema) *dependencyIndex {
+	d := &dependencyIndex{
+		relations:  make(map[string][]string),
+		functions:  make(map[string][]string),
+		types:      make(map[string][]string),
+		collations: make(map[string][]string),
+	}
+	for name, table := range schema.Tables {
+		id := objectID(KindTable, name)
+		d.add(d.relations, name, id)
+		for _, seq := range table.Sequences {
+			seqName, _ := sequenceName(seq.Create)
+			d.add(d.relations, seqName, id)
+		}
+	}
+	for _, seq := range schema.Sequences {
+		d.add(d.relations, seq.Name, objectID(KindSequence, seq.Name))
+	}
+	for name := range schema.Views {
+		d.add(d.relations, schema.Views[name].Name, objectID(KindView, name))
+	}
+	for name := range schema.ForeignTables {
+		d.add(d.relations, schema.ForeignTables[name].Name, objectID(KindForeignTable, name))
+	}
+	for signature, function := range schema.Functions {
+		kind := KindFunction
+		if function.IsProcedure {
+			kind = KindProcedure
+		}
+		d.add(d.functions, function.Name, objectID(kind, signature))
+	}
+	for signature, aggregate := range schema.Aggregates {
+		d.add(d.functions, aggregate.Name, objectID(KindAggregate, signature))
+	}
+	for name := range schema.Types {
+		d.add(d.types, name, objectID(KindType, name))
+	}
+	for name := range schema.Domains {
+		d.add(d.types, name, objectID(KindDomain, name))
+	}
+	for name, collation := range schema.Collations {
+		d.add(d.collations, collation.Name, objectID(KindCollation, name))
+	}
+	for name := range schema.Dictionaries {
+		d.add(d.relations, name, objectID(KindDictionary, name))
+	}
+	return d
+}
+
+// dependencyGraph returns the graph of the dependencies between the printed objects of the schema, with edges from
+// objects to the objects which must be created before them. Tables depend on the tables they reference by foreign
+// keys which are not deferred, and deferred foreign keys and partition index attachments depend on their tables.
+func dependencyGraph(schema *Schema, tableNames []string, deferred map[string]map[string]bool) *graph.Graph {
+	g := graph.New()
+	d := newDependencyIndex(schema)
+
+	for name := range schema.Collations {
+		g.AddNode(objectID(KindCollation, name))
+	}
+	for name := range schema.Dictionaries {
+		g.AddNode(objectID(KindDictionary, name))
+	}
+	for name, config := range schema.Configs {
+		id := objectID(KindConfiguration, name)
+		g.AddNode(id)
+		for _, mapping := range config.Mappings {
+			d.addReferences(g, id, mapping, true)
+		}
+	}
+	for name, typ := range schema.Types {
+		id := objectID(KindType, name)
+		g.AddNode(id)
+		d.addReferences(g, id, typ.Statement[len("CREATE TYPE "+name):], true)
+	}
+	for name, domain := range schema.Domains {
+		id := objectID(KindDomain, name)
+		g.AddNode(id)
+		d.addReferences(g, id, domain.Statement[len("CREATE DOMAIN "+name):], false)
+	}
+	for _, seq := range schema.Sequences {
+		g.AddNode(objectID(KindSequence, seq.Name))
+	}
+
+	for _, tableName := range tableNames {
+		table := schema.Tables[tableName]
+		id := objectID(KindTable, tableName)
+		g.AddNode(id)
+		for _, column := range table.Columns {
+			d.addReferences(g, id, column.Statement, false)
+		}
+		for constraintName, constraint := range table.Constraints {
+			if constraint.Kind == ForeignKeyConstraint {
+				if _, ok := schema.Tables[constraint.RefTable]; ok && !deferred[tableName][constraintName] &&
+					constraint.RefTable != tableName {
+					g.AddEdge(id, objectID(KindTable, constraint.RefTable), constraintName)
+				}
+				continue
+			}
+			d.addReferences(g, id, constraint.Expression+" "+constraint.Where, false)
+		}
+		for _, index := range table.Index {
+			for _, key := range index.Keys {
+				d.addReferences(g, id, key.Expression, false)
+			}
+			d.addReferences(g, id, index.Where, false)
+		}
+		for _, rule := range table.Rules {
+			d.addReferences(g, id, rule, true)
+		}
+
+		for _, index := range table.Index {
+			if len(index.Partitions) > 0 {
+				g.AddEdge(objectID(kindAttachments, ""), id)
+			}
+		}
+		for constraintName := range deferred[tableName] {
+			g.AddEdge(objectID(kindForeignKeys, ""), id)
+			g.AddEdge(objectID(kindForeignKeys, ""), objectID(KindTable, table.Constraints[constraintName].RefTable))
+		}
+	}
+
+	for signature, function := range schema.Functions {
+		kind := KindFunction
+		if function.IsProcedure {
+			kind = KindProcedure
+		}
+		id := objectID(kind, signature)
+		g.AddNode(id)
+		d.addReferences(g, id, strings.Join(function.Arguments, ", ")+" "+function.Returns, true)
+		// bodies are only checked when created if they are standard sql
+		if function.IsAtomic {
+			d.addReferences(g, id, function.Body, true)
+		}
+	}
+	for signature, aggregate := range schema.Aggregates {
+		id := objectID(KindAggregate, signature)
+		g.AddNode(id)
+		for _, option := range aggregateFunctionOptions {
+			if function, ok := aggregate.Options[option]; ok {
+				d.addFunction(g, id, function)
+			}
+		}
+		d.addReferences(g, id, strings.Join(aggregate.Arguments, ", ")+" "+aggregate.Options["stype"], true)
+	}
+	for signature, operator := range schema.Operators {
+		id := objectID(KindOperator, signature)
+		g.AddNode(id)
+		for _, option := range []string{"function", "procedure", "restrict", "join"} {
+			if function, ok := operator.Options[option]; ok {
+				d.addFunction(g, id, function)
+			}
+		}
+	}
+	for signature, cast := range schema.Casts {
+		id := objectID(KindCast, signature)
+		g.AddNode(id)
+		if len(cast.Function) > 0 {
+			d.addFunction(g, id, cast.Function)
+		}
+		d.addReferences(g, id, cast.Source+" "+cast.Target, true)
+	}
+
+	for name, wrapper := range schema.Wrappers {
+		id := objectID(KindWrapper, name)
+		g.AddNode(id)
+		for _, function := range []string{wrapper.Handler, wrapper.Validator} {
+			if len(function) > 0 {
+				d.addFunction(g, id, function)
+			}
+		}
+	}
+	for name, server := range schema.Servers {
+		id := objectID(KindServer, name)
+		g.AddNode(id)
+		if _, ok := schema.Wrappers[server.Wrapper]; ok {
+			g.AddEdge(id, objectID(KindWrapper, server.Wrapper))
+		}
+	}
+	for key, mapping := range schema.UserMappings {
+		id := objectID(KindUserMapping, key)
+		g.AddNode(id)
+		if _, ok := schema.Servers[mapping.Server]; ok {
+			g.AddEdge(id, objectID(KindServer, mapping.Server))
+		}
+	}
+	for name, foreignTable := range schema.ForeignTables {
+		id := objectID(KindForeignTable, name)
+		g.AddNode(id)
+		if _, ok := schema.Servers[foreignTable.Server]; ok {
+			g.AddEdge(id, objectID(KindServer, foreignTable.Server))
+		}
+		for _, column := range foreignTable.Columns {
+			d.addReferences(g, id, column, false)
+		}
+	}
+
+	for name, view := range schema.Views {
+		id := objectID(KindView, name)
+		g.AddNode(id)
+		d.addReferences(g, id, view.Query, true)
+		for _, rule := range view.Rules {
+			d.addReferences(g, id, rule, true)
+		}
+	}
+	for name, publication := range schema.Publications {
+		id := objectID(KindPublication, name)
+		g.AddNode(id)
+		for _, table := range publication.Tables {
+			if _, ok := schema.Tables[table.Name]; ok {
+				g.AddEdge(id, objectID(KindTable, table.Name))
+			}
+		}
+	}
+	for name := range schema.Subscriptions {
+		g.AddNode(objectID(KindSubscription, name))
+	}
+	for name, eventTrigger := range schema.EventTriggers {
+		id := objectID(KindEventTrigger, name)
+		g.AddNode(id)
+		d.addFunction(g, id, eventTrigger.Function)
+	}
+	for _, trigger := range schema.Triggers {
+		id := objectID(KindTrigger, trigger.Table+"."+trigger.Name)
+		g.AddNode(id)
+		if _, ok := schema.Tables[trigger.Table]; ok {
+			g.AddEdge(id, objectID(KindTable, trigger.Table))
+		}
+		d.addFunction(g, id, triggerFunction(trigger.Statement))
+		if index := strings.Index(trigger.Statement, " WHEN "); index != -1 {
+			d.addReferences(g, id, trigger.Statement[index:], false)
+		}
+	}
+	return g
+}
+
+// retainObjects removes the objects whose ids are not kept from the schema
+func retainObjects(schema *Schema, keep map[string]bool) {
+	retain := func(kind string) func(key string) bool {
+		return func(key string) bool { return keep[objectID(kind, key)] }
+	}
+	removed := make(map[string]bool)
+	for name := range schema.Tables {
+		if !keep[objectID(KindTable, name)] {
+			removed[name] = true
+		}
+	}
+	removeTables(schema, removed)
+
+	var sequences []*IndependentSequence
+	for _, seq := range schema.Sequences {
+		if keep[objectID(KindSequence, seq.Name)] {
+			sequences = append(sequences, seq)
+		}
+	}
+	schema.Sequences = sequences
+	var triggers []*Trigger
+	for _, trigger := range schema.Triggers {
+		if keep[objectID(KindTrigger, trigger.Table+"."+trigger.Name)] {
+			triggers = append(triggers, trigger)
+		}
+	}
+	schema.Triggers = triggers
+
+	for signature, function := range schema.Functions {
+		kind := KindFunction
+		if function.IsProcedure {
+			kind = KindProcedure
+		}
+		if !keep[objectID(kind, signature)] {
+			delete(schema.Functions, signature)
+		}
+	}
+	retainKeys(schema.Types, retain(KindType))
+	retainKeys(schema.Domains, retain(KindDomain))
+	retainKeys(schema.Aggregates, retain(KindAggregate))
+	retainKeys(schema.Operators, retain(KindOperator))
+	retainKeys(schema.Casts, retain(KindCast))
+	retainKeys(schema.Collations, retain(KindCollation))
+	retainKeys(schema.Dictionaries, retain(KindDictionary))
+	retainKeys(schema.Configs, retain(KindConfiguration))
+	retainKeys(schema.Views, retain(KindView))
+	retainKeys(schema.EventTriggers, retain(KindEventTrigger))
+	retainKeys(schema.Wrappers, retain(KindWrapper))
+	retainKeys(schema.Servers, retain(KindServer))
+	retainKeys(schema.UserMappings, retain(KindUserMapping))
+	retainKeys(schema.ForeignTables, retain(KindForeignTable))
+	retainKeys(schema.Publications, retain(KindPublication))
+	retainKeys(schema.Subscriptions, retain(KindSubscription))
+}
+
+// retainKeys removes the entries of m whose keys are not retained
+func retainKeys[V any](m map[string]V, retained func(key string) bool) {
+	for key := range m {
+		if !retained(key) {
+			delete(m, key)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]int
+	}{
+		{
+			name:  "Calls and regclass literals",
+			input: "integer DEFAULT nextval('public.orders_id_seq'::regclass) CHECK (public.is_positive (qty))",
+			expected: map[string]int{"integer": referenceName, "DEFAULT": referenceName,
+				"nextval": referenceCall, "orders_id_seq": referenceRegclass, "regclass": referenceName, "CHECK": referenceCall,
+				"is_positive": referenceCall, "public.is_positive": referenceCall, "qty": referenceName},
+		},
+		{
+			name:  "Quoted names, strings and numbers",
+			input: `SELECT "Users".id, 'orders' AS x, 42 FROM public."Users"`,
+			expected: map[string]int{"SELECT": referenceName, "id": referenceName, "Users.id": referenceName,
+				"AS": referenceName, "x": referenceName, "FROM": referenceName, "Users": referenceName,
+				"public.Users": referenceName},
+		},
+	}
+	for _, test := range tests {
+		if !cmp.Equal(references(test.input), test.expected) {
+			t.Error(test.name + " - error")
+		}
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	schema := &Schema{
+		Tables: map[string]*Table{
+			"orders": {
+				Columns: map[string]*Column{
+					"code":     {Statement: "text DEFAULT public.gen_code()"},
+					"qty":      {Statement: "public.positive"},
+					"gen_code": {Statement: "text"},
+				},
+				Constraints: map[string]*Constraint{
+					"orders_user_id_fkey": {Kind: ForeignKeyConstraint, RefTable: "users"},
+				},
+			},
+			"users": {},
+		},
+		Functions: map[string]*Function{
+			"gen_code()":           {Name: "gen_code"},
+			"is_positive(integer)": {Name: "is_positive"},
+			"touch()":              {Name: "touch"},
+		},
+		Domains: map[string]*Domain{
+			"positive": {Name: "positive", Statement: "CREATE DOMAIN positive AS integer CHECK (is_positive(VALUE));"},
+		},
+		Views: map[string]*View{
+			"a_recent": {Name: "a_recent", Query: "SELECT b_orders.id FROM b_orders"},
+			"b_orders": {Name: "b_orders", Query: "SELECT orders.id FROM orders"},
+		},
+		Triggers: []*Trigger{
+			{Name: "orders_touch", Table: "orders",
+				Statement: "CREATE TRIGGER orders_touch BEFORE UPDATE ON orders FOR EACH ROW EXECUTE FUNCTION touch();"},
+		},
+	}
+
+	g := dependencyGraph(schema, []string{"users", "orders"}, nil)
+	expected := map[string][]string{
+		"table:orders":                {"domain:positive", "function:gen_code()", "table:users"},
+		"domain:positive":             {"function:is_positive(integer)"},
+		"view:a_recent":               {"view:b_orders"},
+		"view:b_orders":               {"table:orders"},
+		"trigger:orders.orders_touch": {"function:touch()", "table:orders"},
+		"table:users":                 {},
+	}
+	for id, parents := range expected {
+		if !cmp.Equal(g.Parents(id), parents) {
+			t.Error(id + " - dependencies error")
+		}
+	}
+	if !cmp.Equal(g.Labels("table:orders", "table:users"), []string{"orders_user_id_fkey"}) {
+		t.Error("Foreign key - labels error")
+	}
+
+	deferred := map[string]map[string]bool{"orders": {"orders_user_id_fkey": true}}
+	g = dependencyGraph(schema, []string{"orders", "users"}, deferred)
+	if g.HasEdge("table:orders", "table:users") ||
+		!cmp.Equal(g.Parents("foreign-keys:"), []string{"table:orders", "table:users"}) {
+		t.Error("Deferred foreign key - error")
+	}
+}
@@ -0,0 +1,336 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of table parts compared when diffing schemas
+const (
+	KindColumn     = "column"
+	KindConstraint = "constraint"
+	KindIndex      = "index"
+	KindStatistics = "statistics"
+	KindRule       = "rule"
+)
+
+// Actions of changes between schemas
+const (
+	ActionAdded    = "added"
+	ActionRemoved  = "removed"
+	ActionModified = "modified"
+)
+
+// Change is a difference of an object between two schemas. Added and removed objects hold their definitions in New
+// and Old where they have one, while modified objects hold the old and new values of the changed attribute.
+type Change struct {
+	Action    string `json:"action"`
+	Kind      string `json:"kind"`
+	Object    string `json:"object"`
+	Attribute string `json:"attribute,omitempty"`
+	Old       string `json:"old,omitempty"`
+	New       string `json:"new,omitempty"`
+}
+
+// String returns the change prefixed by +, - or ~ for added, removed and modified objects. The old and new values of
+// modified attributes are left out if either spans several lines.
+func (c *Change) String() string {
+	switch c.Action {
+	case ActionAdded:
+		return fmt.Sprintf("+ %s %s", c.Kind, c.Object)
+	case ActionRemoved:
+		return fmt.Sprintf("- %s %s", c.Kind, c.Object)
+	}
+	s := fmt.Sprintf("~ %s %s %s", c.Kind, c.Object, c.Attribute)
+	if strings.Contains(c.Old, "\n") || strings.Contains(c.New, "\n") {
+		return s
+	}
+	value := func(v string) string {
+		if len(v) == 0 {
+			return "none"
+		}
+		return v
+	}
+	return fmt.Sprintf("%s: %s -> %s", s, value(c.Old), value(c.New))
+}
+
+// diffDefinitions returns the changes between the old and new definitions of objects of the kind, mapped by name
+func diffDefinitions(kind, attribute string, from, to map[string]string) []*Change {
+	var changes []*Change
+	for _, name := range sortedKeys(from) {
+		if _, ok := to[name]; !ok {
+			changes = append(changes, &Change{Action: ActionRemoved, Kind: kind, Object: name, Old: from[name]})
+		}
+	}
+	for _, name := range sortedKeys(to) {
+		old, ok := from[name]
+		if !ok {
+			changes = append(changes, &Change{Action: ActionAdded, Kind: kind, Object: name, New: to[name]})
+		} else if old != to[name] {
+			changes = append(changes, &Change{Action: ActionModified, Kind: kind, Object: name, Attribute: attribute,
+				Old: old, New: to[name]})
+		}
+	}
+	return changes
+}
+
+// definitions maps the objects to their definitions
+func definitions[V any](objects map[string]V, definition func(V) string) map[string]string {
+	defs := make(map[string]string, len(objects))
+	for name, object := range objects {
+		defs[name] = definition(object)
+	}
+	return defs
+}
+
+// columnParts splits a column statement into its type, default expression, nullability and remaining clauses
+func columnParts(stmt string) (string, string, bool, string) {
+	typ, rest := splitColumnStatement(stmt)
+	tokens := tokenize(rest)
+	var defaults, clauses []string
+	notNull := false
+	for i := 0; i < len(tokens); i++ {
+		switch {
+		case tokens[i] == "NOT" && i+1 < len(tokens) && tokens[i+1] == "NULL":
+			notNull = true
+			i++
+		case tokens[i] == "NULL":
+		case tokens[i] == "DEFAULT":
+			for i+1 < len(tokens) && !columnClauseKeywords[tokens[i+1]] {
+				i++
+				defaults = append(defaults, tokens[i])
+			}
+		default:
+			clauses = append(clauses, tokens[i])
+		}
+	}
+	return typ, strings.Join(defaults, " "), notNull, strings.Join(clauses, " ")
+}
+
+// nullability returns the null constraint of a column
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "NULL"
+}
+
+// diffColumn returns the changes of the column's type, default, nullability, remaining clauses and attributes
+func diffColumn(name string, from, to *Column) []*Change {
+	fromType, fromDefault, fromNotNull, fromClauses := columnParts(from.Statement)
+	toType, toDefault, toNotNull, toClauses := columnParts(to.Statement)
+	attributes := [][3]string{
+		{"type", fromType, toType},
+		{"default", fromDefault, toDefault},
+		{"nullability", nullability(fromNotNull), nullability(toNotNull)},
+		{"definition", fromClauses, toClauses},
+		{"statistics", from.Statistics, to.Statistics},
+		{"storage", from.Storage, to.Storage},
+		{"options", strings.Join(from.Options, ", "), strings.Join(to.Options, ", ")},
+	}
+	var changes []*Change
+	for _, attribute := range attributes {
+		if attribute[1] != attribute[2] {
+			changes = append(changes, &Change{Action: ActionModified, Kind: KindColumn, Object: name,
+				Attribute: attribute[0], Old: attribute[1], New: attribute[2]})
+		}
+	}
+	return changes
+}
+
+// tableParts maps the columns, constraints, indexes, statistics, rules and sequences of the table to their
+// definitions by kind
+func tableParts(tableName string, table *Table) map[string]map[string]string {
+	parts := map[string]map[string]string{
+		KindColumn: definitions(table.Columns, func(column *Column) string { return column.Statement }),
+		KindConstraint: definitions(table.Constraints, func(constraint *Constraint) string {
+			return constraint.Statement
+		}),
+		KindIndex:      make(map[string]string),
+		KindStatistics: make(map[string]string),
+		KindRule:       make(map[string]string),
+		KindSequence:   make(map[string]string),
+	}
+	for _, index := range table.Index {
+		parts[KindIndex][index.Name] = strings.Join(append([]string{index.Statement()}, index.AttachStatements()...),
+			"\n")
+	}
+	for _, statistics := range table.Statistics {
+		parts[KindStatistics][statistics.Name] = strings.Join(statistics.Statements(tableName), "\n")
+	}
+	for _, rule := range table.Rules {
+		parts[KindRule][strings.Fields(rule)[2]] = rule
+	}
+	for _, seq := range table.Sequences {
+		name, _ := sequenceName(seq.Create)
+		parts[KindSequence][name] = seq.Create + "\n" + seq.Relation
+	}
+	return parts
+}
+
+// diffTable returns the changes between two definitions of the table
+func diffTable(tableName string, from, to *Table) []*Change {
+	if from.IsDeepEqual(to) {
+		return nil
+	}
+
+	var changes []*Change
+	attributes := [][3]string{
+		{"storage parameters", strings.Join(from.StorageParameters, ", "), strings.Join(to.StorageParameters, ", ")},
+		{"clauses", strings.Join(from.Clauses, " "), strings.Join(to.Clauses, " ")},
+		{"cluster on", from.ClusterOn, to.ClusterOn},
+		{"replica identity", from.ReplicaIdentity, to.ReplicaIdentity},
+	}
+	for _, attribute := range attributes {
+		if attribute[1] != attribute[2] {
+			changes = append(changes, &Change{Action: ActionModified, Kind: KindTable, Object: tableName,
+				Attribute: attribute[0], Old: attribute[1], New: attribute[2]})
+		}
+	}
+
+	fromParts, toParts := tableParts(tableName, from), tableParts(tableName, to)
+	for _, kind := range []string{KindSequence, KindColumn, KindConstraint, KindIndex, KindStatistics, KindRule} {
+		for _, change := range diffDefinitions(kind, "definition", fromParts[kind], toParts[kind]) {
+			if kind == KindColumn {
+				change.Object = tableName + "." + change.Object
+			} else if kind != KindSequence {
+				change.Object += " on " + tableName
+			}
+			if kind != KindColumn || change.Action != ActionModified {
+				changes = append(changes, change)
+			}
+		}
+		if kind != KindColumn {
+			continue
+		}
+		// columns are compared by their parts and attributes, which are left out of their definitions
+		for _, name := range sortedKeys(to.Columns) {
+			if column, ok := from.Columns[name]; ok {
+				changes = append(changes, diffColumn(tableName+"."+name, column, to.Columns[name])...)
+			}
+		}
+	}
+	return changes
+}
+
+// diffBodies returns the changes between the functions or procedures, reporting changed bodies separately from other
+// changes of their definitions
+func diffBodies(kind string, from, to map[string]*Function, procedures bool) []*Change {
+	defs := func(functions map[string]*Function) map[string]string {
+		defs := make(map[string]string)
+		for _, signature := range sortFunctions(functions, procedures) {
+			defs[signature] = functions[signature].Statement
+		}
+		return defs
+	}
+	changes := diffDefinitions(kind, "definition", defs(from), defs(to))
+	for _, change := range changes {
+		if change.Action == ActionModified && from[change.Object].Body != to[change.Object].Body {
+			change.Attribute, change.Old, change.New = "body", strings.TrimSpace(from[change.Object].Body),
+				strings.TrimSpace(to[change.Object].Body)
+		}
+	}
+	return changes
+}
+
+// objectDefinitions maps the objects of the kind in the schema to their definitions, redacting credentials unless
+// showCredentials is set. Tables, functions and procedures are compared by their parts and are left out.
+func objectDefinitions(schema *Schema, kind string, showCredentials bool) map[string]string {
+	switch kind {
+	case KindCollation:
+		return definitions(schema.Collations, func(c *Collation) string { return c.Statement })
+	case KindDictionary:
+		return definitions(schema.Dictionaries, func(d *TextSearchDictionary) string { return d.Statement })
+	case KindConfiguration:
+		return definitions(schema.Configs, func(c *TextSearchConfiguration) string {
+			return strings.Join(c.Statements(), "\n")
+		})
+	case KindType:
+		return definitions(schema.Types, func(t *Type) string { return t.Statement })
+	case KindDomain:
+		return definitions(schema.Domains, func(d *Domain) string { return d.Statement })
+	case KindSequence:
+		sequences := make(map[string]string)
+		for _, seq := range schema.Sequences {
+			sequences[seq.Name] = seq.Statement
+		}
+		return sequences
+	case KindAggregate:
+		return definitions(schema.Aggregates, func(a *Aggregate) string { return a.Statement })
+	case KindOperator:
+		return definitions(schema.Operators, func(o *Operator) string { return o.Statement })
+	case KindCast:
+		return definitions(schema.Casts, func(c *Cast) string { return c.Statement })
+	case KindWrapper:
+		return definitions(schema.Wrappers, func(w *ForeignDataWrapper) string { return w.Statement })
+	case KindServer:
+		return definitions(schema.Servers, func(s *ForeignServer) string { return s.Statement })
+	case KindUserMapping:
+		return definitions(schema.UserMappings, func(m *UserMapping) string { return m.Statement(showCredentials) })
+	case KindForeignTable:
+		return definitions(schema.ForeignTables, func(t *ForeignTable) string { return t.Statement() })
+	case KindView:
+		return definitions(schema.Views, func(v *View) string {
+			return strings.Join(append([]string{v.Statement}, v.Rules...), "\n")
+		})
+	case KindPublication:
+		return definitions(schema.Publications, func(p *Publication) string {
+			return strings.Join(p.Statements(), "\n")
+		})
+	case KindSubscription:
+		return definitions(schema.Subscriptions, func(s *Subscription) string { return s.Statement(showCredentials) })
+	case KindEventTrigger:
+		return definitions(schema.EventTriggers, func(t *EventTrigger) string { return t.Statement })
+	case KindTrigger:
+		triggers := make(map[string]string)
+		for _, trigger := range schema.Triggers {
+			triggers[trigger.Table+"."+trigger.Name] = trigger.Statement
+		}
+		return triggers
+	}
+	return nil
+}
+
+// DiffSchemas returns the changes of objects from one schema to another. Tables are compared by their columns,
+// constraints, indexes, statistics, rules, owned sequences and attributes, with columns compared by type, default,
+// nullability and remaining clauses. Functions and procedures are compared by body, views by query and other objects
+// by definition. Credentials are redacted from the definitions compared.
+func DiffSchemas(from, to *Schema) []*Change {
+	var changes []*Change
+	add := func(kind string, from, to map[string]string) {
+		changes = append(changes, diffDefinitions(kind, "definition", from, to)...)
+	}
+	for _, kind := range []string{KindCollation, KindDictionary, KindConfiguration, KindType, KindDomain, KindSequence} {
+		add(kind, objectDefinitions(from, kind, false), objectDefinitions(to, kind, false))
+	}
+
+	// tables are compared by their parts once both schemas have them
+	noDefinition := func(*Table) string { return "" }
+	add(KindTable, definitions(from.Tables, noDefinition), definitions(to.Tables, noDefinition))
+	for _, name := range sortedKeys(to.Tables) {
+		if table, ok := from.Tables[name]; ok {
+			changes = append(changes, diffTable(name, table, to.Tables[name])...)
+		}
+	}
+
+	changes = append(changes, diffBodies(KindFunction, from.Functions, to.Functions, false)...)
+	changes = append(changes, diffBodies(KindProcedure, from.Functions, to.Functions, true)...)
+	for _, kind := range []string{KindAggregate, KindOperator, KindCast, KindWrapper, KindServer, KindUserMapping,
+		KindForeignTable} {
+		add(kind, objectDefinitions(from, kind, false), objectDefinitions(to, kind, false))
+	}
+
+	for _, change := range diffDefinitions(KindView, "definition", objectDefinitions(from, KindView, false),
+		objectDefinitions(to, KindView, false)) {
+		if change.Action == ActionModified && from.Views[change.Object].Query != to.Views[change.Object].Query {
+			change.Attribute, change.Old, change.New = "query", from.Views[change.Object].Query,
+				to.Views[change.Object].Query
+		}
+		changes = append(changes, change)
+	}
+
+	for _, kind := range []string{KindPublication, KindSubscription, KindEventTrigger, KindTrigger} {
+		add(kind, objectDefinitions(from, kind, false), objectDefinitions(to, kind, false))
+	}
+	return changes
+}
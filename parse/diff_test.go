@@ -0,0 +1,98 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestColumnParts(t *testing.T) {
+	tests := []struct {
+		name            string
+		stmt            string
+		expectedType    string
+		expectedDefault string
+		expectedNotNull bool
+		expectedClauses string
+	}{
+		{name: "Type only", stmt: "text", expectedType: "text"},
+		{
+			name:            "Default and not null",
+			stmt:            "character varying(20) NOT NULL DEFAULT 'none'::character varying",
+			expectedType:    "character varying(20)",
+			expectedDefault: "'none'::character varying",
+			expectedNotNull: true,
+		},
+		{
+			name:            "Remaining clauses",
+			stmt:            "integer COLLATE \"C\" DEFAULT (1 + 2) NULL",
+			expectedType:    "integer",
+			expectedDefault: "(1 + 2)",
+			expectedClauses: "COLLATE \"C\"",
+		},
+	}
+	for _, test := range tests {
+		typ, def, notNull, clauses := columnParts(test.stmt)
+		if typ != test.expectedType || def != test.expectedDefault || notNull != test.expectedNotNull ||
+			clauses != test.expectedClauses {
+			t.Error(test.name + " - column parts error")
+		}
+	}
+}
+
+func TestDiffSchemas(t *testing.T) {
+	base := "CREATE FUNCTION public.count_orders() RETURNS bigint\n    LANGUAGE sql\n" +
+		"    AS $$ SELECT count(*) FROM orders $$;\n\n" +
+		"CREATE VIEW public.order_ids AS\n SELECT orders.id\n   FROM public.orders;\n\n" +
+		"CREATE TABLE public.orders (\n    id integer NOT NULL,\n    user_id integer DEFAULT 0,\n    note text\n);\n\n" +
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);\n\n" +
+		"CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id);\n"
+
+	tests := []struct {
+		name            string
+		from            string
+		to              string
+		expectedChanges []string
+	}{
+		{name: "Same schema", from: base, to: base},
+		{
+			name: "Changed schema",
+			from: base,
+			to: "CREATE FUNCTION public.count_orders() RETURNS bigint\n    LANGUAGE sql\n" +
+				"    AS $$ SELECT count(*) FROM orders WHERE id > 0 $$;\n\n" +
+				"CREATE VIEW public.order_ids AS\n SELECT orders.id,\n    orders.user_id\n   FROM public.orders;\n\n" +
+				"CREATE TABLE public.orders (\n    id integer NOT NULL,\n    user_id bigint NOT NULL,\n" +
+				"    total numeric\n);\n\n" +
+				"CREATE TABLE public.tags (\n    id integer NOT NULL\n);\n\n" +
+				"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);\n\n" +
+				"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_total_check CHECK ((total > 0));\n\n" +
+				"CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id, id);\n\n" +
+				"ALTER TABLE ONLY public.orders ALTER COLUMN id SET STATISTICS 100;\n",
+			expectedChanges: []string{
+				"+ table tags",
+				"- column orders.note",
+				"+ column orders.total",
+				"~ column orders.id statistics: none -> 100",
+				"~ column orders.user_id type: integer -> bigint",
+				"~ column orders.user_id default: 0 -> none",
+				"~ column orders.user_id nullability: NULL -> NOT NULL",
+				"+ constraint orders_total_check on orders",
+				"~ index orders_user_id_idx on orders definition: " +
+					"CREATE INDEX orders_user_id_idx ON orders USING btree (user_id); -> " +
+					"CREATE INDEX orders_user_id_idx ON orders USING btree (user_id, id);",
+				"~ function count_orders() body: SELECT count(*) FROM orders -> " +
+					"SELECT count(*) FROM orders WHERE id > 0",
+				"~ view order_ids query",
+			},
+		},
+	}
+	for _, test := range tests {
+		var changes []string
+		for _, change := range DiffSchemas(readTestSchema(t, test.from), readTestSchema(t, test.to)) {
+			changes = append(changes, change.String())
+		}
+		if !cmp.Equal(changes, test.expectedChanges) {
+			t.Error(test.name + " - changes error")
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EventTrigger is the struct containing logical aspects of an event trigger definition
+type EventTrigger struct {
+	Name      string
+	Event     string
+	Tags      []string
+	Function  string
+	Statement string
+}
+
+// StoreEventTriggers parses sql statements for event triggers and maps them by name. The functions executed by the
+// event triggers must exist in functions.
+// It then returns the remaining lines and event triggers.
+func StoreEventTriggers(lines []string, functions map[string]*Function) ([]string, map[string]*EventTrigger, error) {
+	eventTriggers := make(map[string]*EventTrigger)
+	if len(lines) == 0 {
+		return lines, eventTriggers, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "ALTER EVENT TRIGGER ") {
+			// enable and disable statements follow the create statement
+			name := strings.Split(line, " ")[3]
+			if eventTrigger, ok := eventTriggers[name]; ok {
+				eventTrigger.Statement += "\n" + line
+				continue
+			}
+			return lines, eventTriggers, fmt.Errorf("storing event triggers - event trigger does not exist")
+		} else if !strings.HasPrefix(line, "CREATE EVENT TRIGGER ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		tokens := strings.Split(line, " ")
+		if len(tokens) < 6 || tokens[4] != "ON" {
+			return lines, eventTriggers, fmt.Errorf("storing event triggers - missing event")
+		}
+		eventTrigger := &EventTrigger{
+			Name:      tokens[3],
+			Event:     tokens[5],
+			Statement: line,
+		}
+
+		if index := strings.Index(line, "WHEN TAG IN "); index != -1 {
+			open := index + len("WHEN TAG IN ")
+			if closing := matchingParen(line, open); closing != -1 {
+				eventTrigger.Tags = splitTopLevel(line[open+1:closing], ',')
+			}
+		}
+
+		// PostgreSQL versions before 11 use EXECUTE PROCEDURE instead of EXECUTE FUNCTION
+		index := strings.Index(line, "EXECUTE FUNCTION ")
+		if index == -1 {
+			index = strings.Index(line, "EXECUTE PROCEDURE ")
+		}
+		if index == -1 {
+			return lines, eventTriggers, fmt.Errorf("storing event triggers - missing event trigger function")
+		}
+		function := strings.Fields(line[index:])[2]
+		function, modifier := removeAccessModifier(function[:strings.Index(function, "(")])
+		function = qualifiedName(function, modifier)
+		if _, ok := functions[function+"()"]; !ok {
+			return lines, eventTriggers, fmt.Errorf("storing event triggers - function %s does not exist", function)
+		}
+		eventTrigger.Function = function
+		if modifier == "public" {
+			eventTrigger.Statement = strings.Replace(line, modifier+".", "", -1)
+		}
+
+		eventTriggers[eventTrigger.Name] = eventTrigger
+	}
+
+	return bufferLines, eventTriggers, nil
+}
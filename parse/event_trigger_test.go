@@ -0,0 +1,95 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStoreEventTriggers(t *testing.T) {
+	eventTrigger := "CREATE EVENT TRIGGER audit_ddl ON ddl_command_end WHEN TAG IN ('CREATE TABLE', 'ALTER TABLE') EXECUTE FUNCTION public.audit_ddl();"
+	functions := map[string]*Function{"audit_ddl()": {Name: "audit_ddl"}}
+	expectedEventTrigger := &EventTrigger{
+		Name:      "audit_ddl",
+		Event:     "ddl_command_end",
+		Tags:      []string{"'CREATE TABLE'", "'ALTER TABLE'"},
+		Function:  "audit_ddl",
+		Statement: "CREATE EVENT TRIGGER audit_ddl ON ddl_command_end WHEN TAG IN ('CREATE TABLE', 'ALTER TABLE') EXECUTE FUNCTION audit_ddl();",
+	}
+	expectedDisabledEventTrigger := &EventTrigger{
+		Name:     "audit_ddl",
+		Event:    "ddl_command_end",
+		Tags:     []string{"'CREATE TABLE'", "'ALTER TABLE'"},
+		Function: "audit_ddl",
+		Statement: "CREATE EVENT TRIGGER audit_ddl ON ddl_command_end WHEN TAG IN ('CREATE TABLE', 'ALTER TABLE') EXECUTE FUNCTION audit_ddl();\n" +
+			"ALTER EVENT TRIGGER audit_ddl DISABLE;",
+	}
+
+	tests := []struct {
+		name                  string
+		inputLines            []string
+		inputFunctions        map[string]*Function
+		expectedEventTriggers map[string]*EventTrigger
+		expectedLines         []string
+		expectedError         error
+	}{
+		{
+			name:                  "No input",
+			inputLines:            []string{},
+			inputFunctions:        functions,
+			expectedEventTriggers: map[string]*EventTrigger{},
+			expectedLines:         []string{},
+			expectedError:         nil,
+		},
+		{
+			name:                  "Function does not exist",
+			inputLines:            []string{eventTrigger},
+			inputFunctions:        map[string]*Function{},
+			expectedEventTriggers: map[string]*EventTrigger{},
+			expectedLines:         []string{eventTrigger},
+			expectedError:         fmt.Errorf("storing event triggers - function audit_ddl does not exist"),
+		},
+		{
+			name:                  "Event trigger statements with extra lines",
+			inputLines:            []string{"abc", eventTrigger, "def"},
+			inputFunctions:        functions,
+			expectedEventTriggers: map[string]*EventTrigger{"audit_ddl": expectedEventTrigger},
+			expectedLines:         []string{"abc", "def"},
+			expectedError:         nil,
+		},
+		{
+			name:                  "Disabled event trigger",
+			inputLines:            []string{eventTrigger, "ALTER EVENT TRIGGER audit_ddl DISABLE;"},
+			inputFunctions:        functions,
+			expectedEventTriggers: map[string]*EventTrigger{"audit_ddl": expectedDisabledEventTrigger},
+			expectedLines:         []string{},
+			expectedError:         nil,
+		},
+		{
+			name:           "Function outside the public schema",
+			inputLines:     []string{"CREATE EVENT TRIGGER ddl ON ddl_command_end EXECUTE FUNCTION audit.log_ddl();"},
+			inputFunctions: map[string]*Function{"audit.log_ddl()": {Name: "log_ddl", Schema: "audit"}},
+			expectedEventTriggers: map[string]*EventTrigger{
+				"ddl": {
+					Name:      "ddl",
+					Event:     "ddl_command_end",
+					Function:  "audit.log_ddl",
+					Statement: "CREATE EVENT TRIGGER ddl ON ddl_command_end EXECUTE FUNCTION audit.log_ddl();",
+				},
+			},
+			expectedLines: []string{},
+			expectedError: nil,
+		},
+	}
+	for _, test := range tests {
+		lines, eventTriggers, err := StoreEventTriggers(test.inputLines, test.inputFunctions)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(eventTriggers, test.expectedEventTriggers) {
+			t.Error(test.name + " - event triggers error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
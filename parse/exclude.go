@@ -0,0 +1,88 @@
+package parse
+
+import (
+	"strings"
+)
+
+// DefaultExcludedTables are the tables excluded from the printed schema unless configured otherwise
+const DefaultExcludedTables = "gorp"
+
+// migrationTablePresets maps migration tools to the bookkeeping tables they create. Tables are given by name or by
+// schema and name, where a name of "*" matches every table of the schema.
+var migrationTablePresets = map[string][]string{
+	"gorp":           {"gorp_migrations"},
+	"goose":          {"goose_db_version"},
+	"golang-migrate": {"schema_migrations"},
+	"flyway":         {"flyway_schema_history"},
+	"liquibase":      {"databasechangelog", "databasechangeloglock"},
+	"django":         {"django_migrations"},
+	"rails":          {"schema_migrations", "ar_internal_metadata"},
+	"sqitch":         {"sqitch.*"},
+}
+
+// excludesTable returns whether the exclusion, given by name or by schema and name, matches the table
+func excludesTable(exclusion, tableName string, table *Table) bool {
+	name, schema := removeAccessModifier(exclusion)
+	if len(schema) > 0 && schema != table.Schema {
+		return false
+	}
+	return name == "*" || name == tableName
+}
+
+// expandExclusions replaces the migration tool presets among exclusions with their tables
+func expandExclusions(exclusions []string) []string {
+	var expanded []string
+	for _, exclusion := range exclusions {
+		exclusion = strings.TrimSpace(exclusion)
+		if tables, ok := migrationTablePresets[exclusion]; ok {
+			expanded = append(expanded, tables...)
+		} else if len(exclusion) > 0 {
+			expanded = append(expanded, exclusion)
+		}
+	}
+	return expanded
+}
+
+// ExcludeTables removes the tables matching the exclusions, which are migration tool presets or tables given by name
+// or by schema and name, from the schema along with their triggers and publication memberships. The sequences,
+// constraints, indexes and other objects mapped to the tables are removed with them.
+func ExcludeTables(schema *Schema, exclusions []string) {
+	excluded := make(map[string]bool)
+	for _, exclusion := range expandExclusions(exclusions) {
+		for name, table := range schema.Tables {
+			if excludesTable(exclusion, name, table) {
+				excluded[name] = true
+			}
+		}
+	}
+	if len(excluded) == 0 {
+		return
+	}
+
+	removeTables(schema, excluded)
+}
+
+// removeTables removes the tables from the schema along with their triggers and publication memberships
+func removeTables(schema *Schema, removed map[string]bool) {
+	for name := range removed {
+		delete(schema.Tables, name)
+	}
+
+	var triggers []*Trigger
+	for _, trigger := range schema.Triggers {
+		if !removed[trigger.Table] {
+			triggers = append(triggers, trigger)
+		}
+	}
+	schema.Triggers = triggers
+
+	for _, publication := range schema.Publications {
+		var tables []*PublicationTable
+		for _, table := range publication.Tables {
+			if !removed[table.Name] {
+				tables = append(tables, table)
+			}
+		}
+		publication.Tables = tables
+	}
+}
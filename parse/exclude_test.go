@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExcludeTables(t *testing.T) {
+	newSchema := func() *Schema {
+		return &Schema{
+			Tables: map[string]*Table{
+				"users":                {Schema: "public"},
+				"gorp_migrations":      {Schema: "public"},
+				"schema_migrations":    {Schema: "public"},
+				"ar_internal_metadata": {Schema: "public"},
+				"changes":              {Schema: "sqitch"},
+			},
+			Triggers: []*Trigger{
+				{Name: "users_audit", Table: "users"},
+				{Name: "migrations_audit", Table: "schema_migrations"},
+			},
+			Publications: map[string]*Publication{
+				"cdc": {Name: "cdc", Tables: []*PublicationTable{{Name: "users"}, {Name: "schema_migrations"}}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		input            []string
+		expectedTables   []string
+		expectedTriggers int
+	}{
+		{
+			name:             "No exclusions",
+			input:            []string{""},
+			expectedTables:   []string{"ar_internal_metadata", "changes", "gorp_migrations", "schema_migrations", "users"},
+			expectedTriggers: 2,
+		},
+		{
+			name:             "Default exclusions",
+			input:            []string{DefaultExcludedTables},
+			expectedTables:   []string{"ar_internal_metadata", "changes", "schema_migrations", "users"},
+			expectedTriggers: 2,
+		},
+		{
+			name:             "Presets and tables",
+			input:            []string{"rails", " sqitch", "public.gorp_migrations"},
+			expectedTables:   []string{"users"},
+			expectedTriggers: 1,
+		},
+		{
+			name:             "Table of other schema",
+			input:            []string{"sqitch.users"},
+			expectedTables:   []string{"ar_internal_metadata", "changes", "gorp_migrations", "schema_migrations", "users"},
+			expectedTriggers: 2,
+		},
+	}
+	for _, test := range tests {
+		schema := newSchema()
+		ExcludeTables(schema, test.input)
+		if !cmp.Equal(sortedKeys(schema.Tables), test.expectedTables) {
+			t.Error(test.name + " - tables error")
+		} else if len(schema.Triggers) != test.expectedTriggers {
+			t.Error(test.name + " - triggers error")
+		} else if len(schema.Publications["cdc"].Tables) != test.expectedTriggers {
+			t.Error(test.name + " - publications error")
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package parse
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Object kinds filters can be restricted to
+const (
+	KindTable        = "table"
+	KindSequence     = "sequence"
+	KindFunction     = "function"
+	KindProcedure    = "procedure"
+	KindTrigger      = "trigger"
+	KindView         = "view"
+	KindAggregate    = "aggregate"
+	KindCollation    = "collation"
+	KindForeignTable = "foreign-table"
+)
+
+var filterKinds = map[string]bool{
+	KindTable:        true,
+	KindSequence:     true,
+	KindFunction:     true,
+	KindProcedure:    true,
+	KindTrigger:      true,
+	KindView:         true,
+	KindAggregate:    true,
+	KindCollation:    true,
+	KindForeignTable: true,
+	KindType:         true,
+	KindDomain:       true,
+}
+
+// Filter matches objects by kind, schema and name. Names and schemas are matched by glob patterns, or the schema
+// qualified name is matched by a regular expression.
+type Filter struct {
+	Kind   string
+	Schema string
+	Name   string
+	Regexp *regexp.Regexp
+}
+
+// ParseFilter parses a filter of the form [kind:][schema.]name, where schema and name are glob patterns, or
+// [kind:]/regexp/, where the regular expression is matched against the schema qualified name
+func ParseFilter(s string) (*Filter, error) {
+	filter := &Filter{}
+	if index := strings.Index(s, ":"); index != -1 && filterKinds[s[:index]] {
+		filter.Kind, s = s[:index], s[index+1:]
+	}
+
+	if len(s) > 1 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		exp, err := regexp.Compile(s[1 : len(s)-1])
+		if err != nil {
+			return nil, fmt.Errorf("parsing filter - %s", err)
+		}
+		filter.Regexp = exp
+		return filter, nil
+	}
+
+	filter.Name, filter.Schema = removeAccessModifier(s)
+	for _, pattern := range []string{filter.Schema, filter.Name} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("parsing filter - invalid pattern %s", s)
+		}
+	}
+	if len(filter.Name) == 0 {
+		return nil, fmt.Errorf("parsing filter - missing name")
+	}
+	return filter, nil
+}
+
+// Matches returns whether the filter matches the object. Objects without a schema match any schema pattern.
+func (f *Filter) Matches(kind, schema, name string) bool {
+	if len(f.Kind) > 0 && f.Kind != kind {
+		return false
+	}
+	if f.Regexp != nil {
+		if len(schema) > 0 {
+			return f.Regexp.MatchString(schema + "." + name)
+		}
+		return f.Regexp.MatchString(name)
+	}
+	if len(f.Schema) > 0 && len(schema) > 0 {
+		if ok, _ := path.Match(f.Schema, schema); !ok {
+			return false
+		}
+	}
+	ok, _ := path.Match(f.Name, name)
+	return ok
+}
+
+// isFiltered returns whether the object is left out by the include and exclude filters. An object is left out if it
+// matches an exclude filter, or if there are include filters for its kind and it matches none of them.
+func isFiltered(includes, excludes []*Filter, kind, schema, name string) bool {
+	for _, filter := range excludes {
+		if filter.Matches(kind, schema, name) {
+			return true
+		}
+	}
+	included, restricted := false, false
+	for _, filter := range includes {
+		if len(filter.Kind) == 0 || filter.Kind == kind {
+			restricted = true
+			included = included || filter.Matches(kind, schema, name)
+		}
+	}
+	return restricted && !included
+}
+
+// filterObjects removes the objects left out by the include and exclude filters from objects
+func filterObjects[V any](objects map[string]V, includes, excludes []*Filter, identify func(V) (string, string, string)) {
+	for key, object := range objects {
+		kind, schema, name := identify(object)
+		if isFiltered(includes, excludes, kind, schema, name) {
+			delete(objects, key)
+		}
+	}
+}
+
+// FilterSchema removes the objects left out by the include and exclude filters from the schema. Removed tables are
+// taken out along with the objects mapped to them and their triggers.
+// It then returns warnings for the foreign keys of the remaining tables referencing removed tables.
+func FilterSchema(schema *Schema, includes, excludes []*Filter) []string {
+	if len(includes) == 0 && len(excludes) == 0 {
+		return nil
+	}
+
+	removed := make(map[string]bool)
+	for name, table := range schema.Tables {
+		if isFiltered(includes, excludes, KindTable, table.Schema, name) {
+			removed[name] = true
+		}
+	}
+	removeTables(schema, removed)
+
+	var warnings []string
+	for _, name := range sortedKeys(schema.Tables) {
+		table := schema.Tables[name]
+		for _, constraintName := range sortedKeys(table.Constraints) {
+			constraint := table.Constraints[constraintName]
+			if constraint.Kind == ForeignKeyConstraint && removed[constraint.RefTable] {
+				warnings = append(warnings, fmt.Sprintf("table %s has foreign key %s into excluded table %s", name,
+					constraintName, constraint.RefTable))
+			}
+		}
+	}
+
+	var sequences []*IndependentSequence
+	for _, seq := range schema.Sequences {
+		if !isFiltered(includes, excludes, KindSequence, seq.Schema, seq.Name) {
+			sequences = append(sequences, seq)
+		}
+	}
+	schema.Sequences = sequences
+
+	var triggers []*Trigger
+	for _, trigger := range schema.Triggers {
+		if !isFiltered(includes, excludes, KindTrigger, trigger.Schema, trigger.Name) {
+			triggers = append(triggers, trigger)
+		}
+	}
+	schema.Triggers = triggers
+
+	filterObjects(schema.Functions, includes, excludes, func(f *Function) (string, string, string) {
+		if f.IsProcedure {
+			return KindProcedure, f.Schema, f.Name
+		}
+		return KindFunction, f.Schema, f.Name
+	})
+	filterObjects(schema.Views, includes, excludes, func(v *View) (string, string, string) {
+		return KindView, v.Schema, v.Name
+	})
+	filterObjects(schema.Aggregates, includes, excludes, func(a *Aggregate) (string, string, string) {
+		return KindAggregate, a.Schema, a.Name
+	})
+	filterObjects(schema.Collations, includes, excludes, func(c *Collation) (string, string, string) {
+		return KindCollation, c.Schema, c.Name
+	})
+	filterObjects(schema.Types, includes, excludes, func(t *Type) (string, string, string) {
+		return KindType, t.Schema, t.Name
+	})
+	filterObjects(schema.Domains, includes, excludes, func(d *Domain) (string, string, string) {
+		return KindDomain, d.Schema, d.Name
+	})
+	filterObjects(schema.ForeignTables, includes, excludes, func(t *ForeignTable) (string, string, string) {
+		return KindForeignTable, t.Schema, t.Name
+	})
+
+	return warnings
+}
@@ -0,0 +1,168 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		kind     string
+		schema   string
+		filter   string
+		isRegexp bool
+		hasError bool
+	}{
+		{name: "Name", input: "users", filter: "users"},
+		{name: "Schema and name glob", input: "billing.*", schema: "billing", filter: "*"},
+		{name: "Kind", input: "function:public.*_audit", kind: KindFunction, schema: "public", filter: "*_audit"},
+		{name: "Regexp", input: "table:/^billing\\./", kind: KindTable, isRegexp: true},
+		{name: "Unknown kind", input: "index:users", filter: "index:users"},
+		{name: "Invalid regexp", input: "/(/", hasError: true},
+		{name: "Invalid glob", input: "users[", hasError: true},
+		{name: "Missing name", input: "table:", hasError: true},
+	}
+	for _, test := range tests {
+		filter, err := ParseFilter(test.input)
+		if test.hasError {
+			if err == nil {
+				t.Error(test.name + " - error expected")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(test.name + " - unexpected error")
+		} else if filter.Kind != test.kind || filter.Schema != test.schema || filter.Name != test.filter ||
+			(filter.Regexp != nil) != test.isRegexp {
+			t.Error(test.name + " - filter error")
+		}
+	}
+}
+
+func TestFilterSchema(t *testing.T) {
+	newSchema := func() *Schema {
+		return &Schema{
+			Tables: map[string]*Table{
+				"invoices": {Schema: "billing", Constraints: map[string]*Constraint{
+					"invoices_user_id_fkey": {Kind: ForeignKeyConstraint, RefTable: "users"},
+				}},
+				"payments": {Schema: "billing"},
+				"users":    {Schema: "public"},
+			},
+			Sequences: []*IndependentSequence{
+				{Name: "invoice_number", Schema: "billing", Statement: "CREATE SEQUENCE invoice_number;"},
+				{Name: "ticket_number", Statement: "CREATE SEQUENCE ticket_number;"},
+				{Name: "invoice_numbers", Schema: "public", Statement: "CREATE SEQUENCE invoice_numbers;"},
+			},
+			Functions: map[string]*Function{
+				"billing.total()": {Name: "total", Schema: "billing"},
+				"public.touch()":  {Name: "touch", Schema: "public"},
+			},
+			Triggers: []*Trigger{
+				{Name: "invoices_touch", Table: "invoices", Schema: "billing"},
+				{Name: "users_touch", Table: "users", Schema: "public"},
+			},
+			Publications: map[string]*Publication{
+				"cdc": {Name: "cdc", Tables: []*PublicationTable{{Name: "invoices"}, {Name: "users"}}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		includes          []string
+		excludes          []string
+		expectedTables    []string
+		expectedSequences []string
+		expectedFunctions []string
+		expectedTriggers  int
+		expectedWarnings  []string
+	}{
+		{
+			name:              "No filters",
+			expectedTables:    []string{"invoices", "payments", "users"},
+			expectedSequences: []string{"invoice_number", "ticket_number", "invoice_numbers"},
+			expectedFunctions: []string{"billing.total()", "public.touch()"},
+			expectedTriggers:  2,
+		},
+		{
+			name:              "Include schema",
+			includes:          []string{"billing.*"},
+			expectedTables:    []string{"invoices", "payments"},
+			expectedSequences: []string{"invoice_number", "ticket_number"},
+			expectedFunctions: []string{"billing.total()"},
+			expectedTriggers:  1,
+			expectedWarnings:  []string{"table invoices has foreign key invoices_user_id_fkey into excluded table users"},
+		},
+		{
+			name:              "Include kind only restricts kind",
+			includes:          []string{"table:/^billing\\.inv/"},
+			expectedTables:    []string{"invoices"},
+			expectedSequences: []string{"invoice_number", "ticket_number", "invoice_numbers"},
+			expectedFunctions: []string{"billing.total()", "public.touch()"},
+			expectedTriggers:  1,
+			expectedWarnings:  []string{"table invoices has foreign key invoices_user_id_fkey into excluded table users"},
+		},
+		{
+			name:              "Exclude wins over include",
+			includes:          []string{"*"},
+			excludes:          []string{"sequence:*_number", "trigger:users_*", "function:total"},
+			expectedTables:    []string{"invoices", "payments", "users"},
+			expectedSequences: []string{"invoice_numbers"},
+			expectedFunctions: []string{"public.touch()"},
+			expectedTriggers:  1,
+		},
+		{
+			name:              "Exclude other schema keeps schema qualified sequences",
+			excludes:          []string{"archive.*"},
+			expectedTables:    []string{"invoices", "payments", "users"},
+			expectedSequences: []string{"invoice_number", "invoice_numbers"},
+			expectedFunctions: []string{"billing.total()", "public.touch()"},
+			expectedTriggers:  2,
+		},
+		{
+			name:              "Include schema regexp",
+			includes:          []string{"/^public\\./"},
+			expectedTables:    []string{"users"},
+			expectedSequences: []string{"invoice_numbers"},
+			expectedFunctions: []string{"public.touch()"},
+			expectedTriggers:  1,
+		},
+	}
+	for _, test := range tests {
+		var includes, excludes []*Filter
+		for _, s := range test.includes {
+			filter, _ := ParseFilter(s)
+			includes = append(includes, filter)
+		}
+		for _, s := range test.excludes {
+			filter, _ := ParseFilter(s)
+			excludes = append(excludes, filter)
+		}
+
+		schema := newSchema()
+		warnings := FilterSchema(schema, includes, excludes)
+		if !cmp.Equal(sortedKeys(schema.Tables), test.expectedTables) {
+			t.Error(test.name + " - tables error")
+		} else if !cmp.Equal(sequenceNames(schema.Sequences), test.expectedSequences) {
+			t.Error(test.name + " - sequences error")
+		} else if !cmp.Equal(sortedKeys(schema.Functions), test.expectedFunctions) {
+			t.Error(test.name + " - functions error")
+		} else if len(schema.Triggers) != test.expectedTriggers {
+			t.Error(test.name + " - triggers error")
+		} else if !cmp.Equal(warnings, test.expectedWarnings) {
+			t.Error(test.name + " - warnings error")
+		}
+	}
+}
+
+func sequenceNames(seqs []*IndependentSequence) []string {
+	var names []string
+	for _, seq := range seqs {
+		names = append(names, seq.Name)
+	}
+	return names
+}
@@ -0,0 +1,284 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ForeignDataWrapper is the struct containing logical aspects of a foreign data wrapper definition
+type ForeignDataWrapper struct {
+	Name      string
+	Handler   string
+	Validator string
+	Options   []string
+	Statement string
+}
+
+// ForeignServer is the struct containing logical aspects of a foreign server definition
+type ForeignServer struct {
+	Name      string
+	Type      string
+	Version   string
+	Wrapper   string
+	Options   []string
+	Statement string
+}
+
+// UserMapping is the struct containing logical aspects of a user mapping for a foreign server
+type UserMapping struct {
+	User    string
+	Server  string
+	Options []string
+}
+
+// ForeignTable is the struct containing logical aspects of a foreign table definition
+type ForeignTable struct {
+	Name    string
+	Schema  string
+	Columns []string
+	Server  string
+	Options []string
+}
+
+// redactedValue replaces the values of credential options when printing user mappings
+const redactedValue = "'********'"
+
+// credentialOptions are the user mapping options holding credentials
+var credentialOptions = map[string]bool{
+	"password":    true,
+	"passfile":    true,
+	"sslpassword": true,
+	"sslkey":      true,
+}
+
+// optionsList returns the elements of the OPTIONS ( ... ) clause in s, if any
+func optionsList(s string) []string {
+	index := indexKeyword(s, "OPTIONS")
+	if index == -1 {
+		return nil
+	}
+	open := strings.Index(s[index:], "(")
+	if open == -1 {
+		return nil
+	}
+	open += index
+	closing := matchingParen(s, open)
+	if closing == -1 {
+		return nil
+	}
+	return splitTopLevel(s[open+1:closing], ',')
+}
+
+// optionName returns the unquoted name of a "name 'value'" option
+func optionName(option string) string {
+	return strings.Trim(strings.Split(option, " ")[0], "\"")
+}
+
+func formatOptions(options []string) string {
+	return "OPTIONS (" + strings.Join(options, ", ") + ")"
+}
+
+// keywordValue returns the token following keyword in s, or an empty string if keyword is not found
+func keywordValue(s, keyword string) string {
+	index := indexKeyword(s, keyword)
+	if index == -1 {
+		return ""
+	}
+	tokens := tokenize(s[index+len(keyword):])
+	if len(tokens) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(tokens[0], ";")
+}
+
+// Key returns the user and server of the user mapping, which identifies it
+func (m *UserMapping) Key() string {
+	return m.User + "@" + m.Server
+}
+
+// Statement returns the create statement of the user mapping, redacting credentials unless showCredentials is set
+func (m *UserMapping) Statement(showCredentials bool) string {
+	stmt := "CREATE USER MAPPING FOR " + m.User + " SERVER " + m.Server
+	if len(m.Options) == 0 {
+		return stmt + ";"
+	}
+	options := make([]string, len(m.Options))
+	for i, option := range m.Options {
+		if !showCredentials && credentialOptions[optionName(option)] {
+			option = strings.Split(option, " ")[0] + " " + redactedValue
+		}
+		options[i] = option
+	}
+	return stmt + " " + formatOptions(options) + ";"
+}
+
+// Statement returns the create statement of the foreign table
+func (t *ForeignTable) Statement() string {
+	stmt := "CREATE FOREIGN TABLE " + t.Name + " (\n"
+	for i, column := range t.Columns {
+		stmt += "    " + column
+		if i < len(t.Columns)-1 {
+			stmt += ","
+		}
+		stmt += "\n"
+	}
+	stmt += ")\nSERVER " + t.Server
+	if len(t.Options) > 0 {
+		stmt += "\n" + formatOptions(t.Options)
+	}
+	return stmt + ";"
+}
+
+// StoreForeignDataWrappers parses sql statements for foreign data wrappers and maps them by name.
+// It then returns the remaining lines and foreign data wrappers.
+func StoreForeignDataWrappers(lines []string) ([]string, map[string]*ForeignDataWrapper, error) {
+	wrappers := make(map[string]*ForeignDataWrapper)
+	if len(lines) == 0 {
+		return lines, wrappers, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE FOREIGN DATA WRAPPER ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		wrapper := &ForeignDataWrapper{
+			Name:      strings.TrimSuffix(strings.Split(line, " ")[4], ";"),
+			Options:   optionsList(line),
+			Statement: line,
+		}
+		if len(wrapper.Options) > 0 {
+			wrapper.Statement = strings.TrimSuffix(line[:indexKeyword(line, "OPTIONS")], " ") + " " +
+				formatOptions(wrapper.Options) + ";"
+		}
+		var handlerModifier, validatorModifier string
+		wrapper.Handler, handlerModifier = removeAccessModifier(keywordValue(line, "HANDLER"))
+		wrapper.Validator, validatorModifier = removeAccessModifier(keywordValue(line, "VALIDATOR"))
+		for _, modifier := range []string{handlerModifier, validatorModifier} {
+			if len(modifier) > 0 {
+				wrapper.Statement = strings.Replace(wrapper.Statement, modifier+".", "", -1)
+			}
+		}
+
+		wrappers[wrapper.Name] = wrapper
+	}
+
+	return bufferLines, wrappers, nil
+}
+
+// StoreForeignServers parses sql statements for foreign servers and maps them by name.
+// It then returns the remaining lines and foreign servers.
+func StoreForeignServers(lines []string) ([]string, map[string]*ForeignServer, error) {
+	servers := make(map[string]*ForeignServer)
+	if len(lines) == 0 {
+		return lines, servers, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE SERVER ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		index := strings.Index(line, " FOREIGN DATA WRAPPER ")
+		if index == -1 {
+			return lines, servers, fmt.Errorf("storing foreign servers - missing foreign data wrapper")
+		}
+		server := &ForeignServer{
+			Name:    strings.Split(line, " ")[2],
+			Type:    strings.Trim(keywordValue(line[:index], "TYPE"), "'"),
+			Version: strings.Trim(keywordValue(line[:index], "VERSION"), "'"),
+			Wrapper: keywordValue(line, "WRAPPER"),
+			Options: optionsList(line),
+		}
+		server.Statement = strings.TrimSuffix(line[:index], " ") + " FOREIGN DATA WRAPPER " + server.Wrapper
+		if len(server.Options) > 0 {
+			server.Statement += " " + formatOptions(server.Options)
+		}
+		server.Statement += ";"
+
+		servers[server.Name] = server
+	}
+
+	return bufferLines, servers, nil
+}
+
+// StoreUserMappings parses sql statements for user mappings and maps them by user and server. The servers of the
+// user mappings must exist in servers.
+// It then returns the remaining lines and user mappings.
+func StoreUserMappings(lines []string, servers map[string]*ForeignServer) ([]string, map[string]*UserMapping, error) {
+	mappings := make(map[string]*UserMapping)
+	if len(lines) == 0 {
+		return lines, mappings, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE USER MAPPING FOR ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		mapping := &UserMapping{
+			User:    strings.Split(line, " ")[4],
+			Server:  keywordValue(line, "SERVER"),
+			Options: optionsList(line),
+		}
+		if _, ok := servers[mapping.Server]; !ok {
+			return lines, mappings, fmt.Errorf("storing user mappings - server does not exist")
+		}
+
+		mappings[mapping.Key()] = mapping
+	}
+
+	return bufferLines, mappings, nil
+}
+
+// StoreForeignTables parses sql statements for foreign tables and maps them by name. The servers of the foreign tables
+// must exist in servers.
+// It then returns the remaining lines and foreign tables.
+func StoreForeignTables(lines []string, servers map[string]*ForeignServer) ([]string, map[string]*ForeignTable, error) {
+	foreignTables := make(map[string]*ForeignTable)
+	if len(lines) == 0 {
+		return lines, foreignTables, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE FOREIGN TABLE ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		rest := line[len("CREATE FOREIGN TABLE "):]
+		open := strings.Index(rest, "(")
+		closing := matchingParen(rest, open)
+		if open == -1 || closing == -1 {
+			return lines, foreignTables, fmt.Errorf("storing foreign tables - missing foreign table columns")
+		}
+		foreignTable := &ForeignTable{
+			Server:  keywordValue(rest[closing+1:], "SERVER"),
+			Options: optionsList(rest[closing+1:]),
+		}
+		foreignTable.Name, foreignTable.Schema = removeAccessModifier(strings.TrimSpace(rest[:open]))
+		if _, ok := servers[foreignTable.Server]; !ok {
+			return lines, foreignTables, fmt.Errorf("storing foreign tables - server does not exist")
+		}
+
+		for _, column := range splitTopLevel(rest[open+1:closing], ',') {
+			// reformat column options squashed over multiple lines
+			if index := indexKeyword(column, "OPTIONS"); index != -1 {
+				closingOptions := matchingParen(column, strings.Index(column[index:], "(")+index)
+				column = column[:index] + formatOptions(optionsList(column)) + column[closingOptions+1:]
+			}
+			foreignTable.Columns = append(foreignTable.Columns, column)
+		}
+
+		foreignTables[foreignTable.Name] = foreignTable
+	}
+
+	return bufferLines, foreignTables, nil
+}
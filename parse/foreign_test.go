@@ -0,0 +1,226 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStoreForeignDataWrappers(t *testing.T) {
+	lines, wrappers, err := StoreForeignDataWrappers([]string{
+		"abc",
+		"CREATE FOREIGN DATA WRAPPER dummy HANDLER public.dummy_handler VALIDATOR public.dummy_validator OPTIONS ( debug 'true' );",
+	})
+	expectedWrappers := map[string]*ForeignDataWrapper{
+		"dummy": {
+			Name:      "dummy",
+			Handler:   "dummy_handler",
+			Validator: "dummy_validator",
+			Options:   []string{"debug 'true'"},
+			Statement: "CREATE FOREIGN DATA WRAPPER dummy HANDLER dummy_handler VALIDATOR dummy_validator OPTIONS (debug 'true');",
+		},
+	}
+	if err != nil {
+		t.Error("Foreign data wrapper - fatal error")
+	} else if !cmp.Equal(wrappers, expectedWrappers) {
+		t.Error("Foreign data wrapper - wrappers error")
+	} else if !similarLines(lines, []string{"abc"}) {
+		t.Error("Foreign data wrapper - lines error")
+	}
+}
+
+func TestStoreForeignServers(t *testing.T) {
+	server := "CREATE SERVER reporting FOREIGN DATA WRAPPER postgres_fdw OPTIONS ( dbname 'reporting', host 'reporting.internal' );"
+	expectedServer := &ForeignServer{
+		Name:      "reporting",
+		Wrapper:   "postgres_fdw",
+		Options:   []string{"dbname 'reporting'", "host 'reporting.internal'"},
+		Statement: "CREATE SERVER reporting FOREIGN DATA WRAPPER postgres_fdw OPTIONS (dbname 'reporting', host 'reporting.internal');",
+	}
+
+	tests := []struct {
+		name            string
+		input           []string
+		expectedServers map[string]*ForeignServer
+		expectedLines   []string
+		expectedError   error
+	}{
+		{
+			name:            "No input",
+			input:           []string{},
+			expectedServers: map[string]*ForeignServer{},
+			expectedLines:   []string{},
+			expectedError:   nil,
+		},
+		{
+			name:            "Server statements with extra lines",
+			input:           []string{"abc", server, "def"},
+			expectedServers: map[string]*ForeignServer{"reporting": expectedServer},
+			expectedLines:   []string{"abc", "def"},
+			expectedError:   nil,
+		},
+		{
+			name:  "Server with type and version",
+			input: []string{"CREATE SERVER legacy TYPE 'oracle' VERSION '11' FOREIGN DATA WRAPPER oracle_fdw;"},
+			expectedServers: map[string]*ForeignServer{
+				"legacy": {
+					Name:      "legacy",
+					Type:      "oracle",
+					Version:   "11",
+					Wrapper:   "oracle_fdw",
+					Statement: "CREATE SERVER legacy TYPE 'oracle' VERSION '11' FOREIGN DATA WRAPPER oracle_fdw;",
+				},
+			},
+			expectedLines: []string{},
+			expectedError: nil,
+		},
+		{
+			name:            "Missing foreign data wrapper",
+			input:           []string{"CREATE SERVER reporting;"},
+			expectedServers: map[string]*ForeignServer{},
+			expectedLines:   []string{"CREATE SERVER reporting;"},
+			expectedError:   fmt.Errorf("storing foreign servers - missing foreign data wrapper"),
+		},
+	}
+	for _, test := range tests {
+		lines, servers, err := StoreForeignServers(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(servers, test.expectedServers) {
+			t.Error(test.name + " - servers error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
+
+func TestStoreUserMappings(t *testing.T) {
+	mapping := "CREATE USER MAPPING FOR app SERVER reporting OPTIONS ( password 'hunter2', \"user\" 'report_reader' );"
+	servers := map[string]*ForeignServer{"reporting": {Name: "reporting"}}
+	expectedMapping := &UserMapping{
+		User:    "app",
+		Server:  "reporting",
+		Options: []string{"password 'hunter2'", "\"user\" 'report_reader'"},
+	}
+
+	tests := []struct {
+		name             string
+		inputLines       []string
+		inputServers     map[string]*ForeignServer
+		expectedMappings map[string]*UserMapping
+		expectedLines    []string
+		expectedError    error
+	}{
+		{
+			name:             "No input",
+			inputLines:       []string{},
+			inputServers:     servers,
+			expectedMappings: map[string]*UserMapping{},
+			expectedLines:    []string{},
+			expectedError:    nil,
+		},
+		{
+			name:             "Server does not exist",
+			inputLines:       []string{mapping},
+			inputServers:     map[string]*ForeignServer{},
+			expectedMappings: map[string]*UserMapping{},
+			expectedLines:    []string{mapping},
+			expectedError:    fmt.Errorf("storing user mappings - server does not exist"),
+		},
+		{
+			name:             "User mapping statements with extra lines",
+			inputLines:       []string{"abc", mapping, "def"},
+			inputServers:     servers,
+			expectedMappings: map[string]*UserMapping{"app@reporting": expectedMapping},
+			expectedLines:    []string{"abc", "def"},
+			expectedError:    nil,
+		},
+	}
+	for _, test := range tests {
+		lines, mappings, err := StoreUserMappings(test.inputLines, test.inputServers)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(mappings, test.expectedMappings) {
+			t.Error(test.name + " - user mappings error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+
+	redacted := "CREATE USER MAPPING FOR app SERVER reporting OPTIONS (password '********', \"user\" 'report_reader');"
+	if expectedMapping.Statement(false) != redacted {
+		t.Error("Redacted user mapping statement error")
+	}
+	shown := "CREATE USER MAPPING FOR app SERVER reporting OPTIONS (password 'hunter2', \"user\" 'report_reader');"
+	if expectedMapping.Statement(true) != shown {
+		t.Error("User mapping statement error")
+	}
+}
+
+func TestStoreForeignTables(t *testing.T) {
+	foreignTable := "CREATE FOREIGN TABLE public.remote_orders ( id integer OPTIONS ( column_name 'id' ) NOT NULL, total numeric ) " +
+		"SERVER reporting OPTIONS ( schema_name 'public', table_name 'orders' );"
+	servers := map[string]*ForeignServer{"reporting": {Name: "reporting"}}
+	expectedForeignTable := &ForeignTable{
+		Name:    "remote_orders",
+		Schema:  "public",
+		Columns: []string{"id integer OPTIONS (column_name 'id') NOT NULL", "total numeric"},
+		Server:  "reporting",
+		Options: []string{"schema_name 'public'", "table_name 'orders'"},
+	}
+
+	tests := []struct {
+		name                  string
+		inputLines            []string
+		inputServers          map[string]*ForeignServer
+		expectedForeignTables map[string]*ForeignTable
+		expectedLines         []string
+		expectedError         error
+	}{
+		{
+			name:                  "No input",
+			inputLines:            []string{},
+			inputServers:          servers,
+			expectedForeignTables: map[string]*ForeignTable{},
+			expectedLines:         []string{},
+			expectedError:         nil,
+		},
+		{
+			name:                  "Server does not exist",
+			inputLines:            []string{foreignTable},
+			inputServers:          map[string]*ForeignServer{},
+			expectedForeignTables: map[string]*ForeignTable{},
+			expectedLines:         []string{foreignTable},
+			expectedError:         fmt.Errorf("storing foreign tables - server does not exist"),
+		},
+		{
+			name:                  "Foreign table statements with extra lines",
+			inputLines:            []string{"abc", foreignTable, "def"},
+			inputServers:          servers,
+			expectedForeignTables: map[string]*ForeignTable{"remote_orders": expectedForeignTable},
+			expectedLines:         []string{"abc", "def"},
+			expectedError:         nil,
+		},
+	}
+	for _, test := range tests {
+		lines, foreignTables, err := StoreForeignTables(test.inputLines, test.inputServers)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(foreignTables, test.expectedForeignTables) {
+			t.Error(test.name + " - foreign tables error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+
+	expectedStatement := "CREATE FOREIGN TABLE remote_orders (\n" +
+		"    id integer OPTIONS (column_name 'id') NOT NULL,\n" +
+		"    total numeric\n" +
+		")\n" +
+		"SERVER reporting\n" +
+		"OPTIONS (schema_name 'public', table_name 'orders');"
+	if expectedForeignTable.Statement() != expectedStatement {
+		t.Error("Foreign table statement error")
+	}
+}
@@ -0,0 +1,266 @@
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Function is the struct containing logical aspects of a function or procedure definition
+type Function struct {
+	Name        string
+	Schema      string
+	Arguments   []string
+	Returns     string
+	Language    string
+	Attributes  []string
+	Body        string
+	IsOrReplace bool
+	IsAtomic    bool
+	IsProcedure bool
+	Statement   string
+}
+
+// functionClauseKeywords are the keywords starting a clause in a function definition
+var functionClauseKeywords = map[string]bool{
+	"LANGUAGE":  true,
+	"TRANSFORM": true,
+	"WINDOW":    true,
+	"IMMUTABLE": true,
+	"STABLE":    true,
+	"VOLATILE":  true,
+	"NOT":       true,
+	"LEAKPROOF": true,
+	"CALLED":    true,
+	"RETURNS":   true,
+	"STRICT":    true,
+	"EXTERNAL":  true,
+	"SECURITY":  true,
+	"PARALLEL":  true,
+	"COST":      true,
+	"ROWS":      true,
+	"SUPPORT":   true,
+	"SET":       true,
+}
+
+// multiWordTypeExp matches type names containing spaces, which cannot be told apart from named arguments otherwise
+var multiWordTypeExp = regexp.MustCompile(`^(double precision|character varying|national character varying|` +
+	`national character|bit varying|timestamp|time|interval)(\([0-9, ]+\))?( with(out)? time zone)?(\[\])*$`)
+
+// splitArgument splits a function argument declaration into its mode, name and type
+func splitArgument(arg string) (string, string, string) {
+	if index := indexKeyword(arg, "DEFAULT"); index != -1 {
+		arg = arg[:index]
+	} else if index := strings.Index(arg, " = "); index != -1 {
+		arg = arg[:index]
+	}
+	arg = strings.TrimSpace(arg)
+
+	mode := "IN"
+	for _, m := range []string{"IN", "OUT", "INOUT", "VARIADIC"} {
+		if strings.HasPrefix(arg, m+" ") {
+			mode = m
+			arg = strings.TrimSpace(arg[len(m):])
+			break
+		}
+	}
+
+	spaceIndex := strings.Index(arg, " ")
+	if spaceIndex == -1 || multiWordTypeExp.MatchString(arg) {
+		return mode, "", arg
+	}
+	return mode, arg[:spaceIndex], arg[spaceIndex+1:]
+}
+
+// qualifiedName returns the name qualified by its schema unless the schema is public, which keeps same-named objects
+// of different schemas apart
+func qualifiedName(name, schema string) string {
+	if len(schema) > 0 && schema != "public" {
+		return schema + "." + name
+	}
+	return name
+}
+
+// Signature returns the qualified name and argument types of the function, which identifies it among its overloads.
+// Output arguments are part of the signature of procedures as they are passed in CALL statements.
+func (f *Function) Signature() string {
+	var types []string
+	for _, arg := range f.Arguments {
+		mode, _, argType := splitArgument(arg)
+		if mode == "OUT" && !f.IsProcedure {
+			continue
+		}
+		types = append(types, argType)
+	}
+	return qualifiedName(f.Name, f.Schema) + "(" + strings.Join(types, ", ") + ")"
+}
+
+func isCreateFunction(line string) bool {
+	for _, prefix := range []string{"CREATE ", "CREATE OR REPLACE "} {
+		if strings.HasPrefix(line, prefix+"FUNCTION ") || strings.HasPrefix(line, prefix+"PROCEDURE ") {
+			return true
+		}
+	}
+	return false
+}
+
+// functionEnd returns the index of the last line of the function definition starting at lines[start]
+func functionEnd(lines []string, start int) (int, error) {
+	text := lines[start]
+	for j := start; j < len(lines); j++ {
+		if j > start {
+			text += "\n" + lines[j]
+		}
+		// SQL-standard bodies contain semicolons outside quotes and are terminated by END
+		if indexKeyword(text, "BEGIN ATOMIC") != -1 {
+			if strings.TrimSpace(lines[j]) == "END;" {
+				return j, nil
+			}
+		} else if statementEnd(text) != -1 {
+			return j, nil
+		}
+	}
+	return start, fmt.Errorf("storing functions - function is not terminated")
+}
+
+// parseFunctionClauses parses the return type, language and attributes following the function arguments
+func parseFunctionClauses(function *Function, clauses string) {
+	tokens := tokenize(clauses)
+	i := 0
+	if len(tokens) > 1 && tokens[0] == "RETURNS" && tokens[1] != "NULL" {
+		i++
+		var returns []string
+		for ; i < len(tokens) && !functionClauseKeywords[tokens[i]]; i++ {
+			returns = append(returns, tokens[i])
+		}
+		function.Returns = strings.Join(returns, " ")
+	}
+
+	for i < len(tokens) {
+		clause := []string{tokens[i]}
+		for i++; i < len(tokens); i++ {
+			prev := clause[len(clause)-1]
+			if functionClauseKeywords[tokens[i]] && !(prev == "EXTERNAL" && tokens[i] == "SECURITY") &&
+				!(prev == "NOT" && tokens[i] == "LEAKPROOF") {
+				break
+			}
+			clause = append(clause, tokens[i])
+		}
+
+		if clause[0] == "LANGUAGE" && len(clause) > 1 {
+			function.Language = clause[1]
+		} else {
+			function.Attributes = append(function.Attributes, strings.Join(clause, " "))
+		}
+	}
+}
+
+// parseFunctionBody parses the function body following the AS keyword
+func parseFunctionBody(body string) string {
+	body = strings.TrimSpace(body)
+	body = strings.TrimSuffix(body, ";")
+	if tag := dollarTagAt(body, 0); len(tag) > 0 {
+		end := skipQuoted(body, 0)
+		return body[len(tag) : end-len(tag)]
+	} else if len(body) > 0 && body[0] == '\'' {
+		end := skipQuoted(body, 0)
+		return strings.Replace(body[1:end-1], "''", "'", -1)
+	}
+	return body
+}
+
+// parseFunction parses a complete function or procedure definition
+func parseFunction(stmt string) (*Function, error) {
+	function := &Function{
+		IsOrReplace: strings.HasPrefix(stmt, "CREATE OR REPLACE "),
+	}
+
+	keyword := "FUNCTION "
+	if index := strings.Index(stmt, " PROCEDURE "); index != -1 && index < strings.Index(stmt, "(") {
+		function.IsProcedure = true
+		keyword = "PROCEDURE "
+	}
+	rest := stmt[strings.Index(stmt, keyword)+len(keyword):]
+	open := strings.Index(rest, "(")
+	if open == -1 {
+		return nil, fmt.Errorf("storing functions - missing function arguments")
+	}
+	closing := matchingParen(rest, open)
+	if closing == -1 {
+		return nil, fmt.Errorf("storing functions - missing function arguments")
+	}
+	function.Name, function.Schema = removeAccessModifier(strings.TrimSpace(rest[:open]))
+	function.Arguments = splitTopLevel(rest[open+1:closing], ',')
+
+	tail := rest[closing+1:]
+	if index := indexKeyword(tail, "BEGIN ATOMIC"); index != -1 {
+		function.IsAtomic = true
+		parseFunctionClauses(function, tail[:index])
+		body := strings.TrimSpace(tail[index+len("BEGIN ATOMIC"):])
+		function.Body = strings.TrimSpace(strings.TrimSuffix(body, "END;"))
+	} else if index := indexKeyword(tail, "AS"); index != -1 {
+		parseFunctionClauses(function, tail[:index])
+		function.Body = parseFunctionBody(tail[index+len("AS"):])
+	} else {
+		return nil, fmt.Errorf("storing functions - missing function body")
+	}
+
+	return function, nil
+}
+
+// StoreFunctions parses sql statements for functions and procedures and maps them by signature.
+// It then returns the remaining lines and functions.
+// Note: Must run before any multi-line statements are squashed as function bodies span multiple lines.
+func StoreFunctions(lines []string) ([]string, map[string]*Function, error) {
+	functions := make(map[string]*Function)
+	if len(lines) == 0 {
+		return lines, functions, nil
+	}
+
+	var bufferLines []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if !isCreateFunction(line) {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		j, err := functionEnd(lines, i)
+		if err != nil {
+			return lines, functions, err
+		}
+		function, err := parseFunction(strings.Join(lines[i:j+1], "\n"))
+		if err != nil {
+			return lines, functions, err
+		}
+
+		// functions outside the public schema keep their qualifier to tell them apart from same-named functions
+		statementLines := append([]string{}, lines[i:j+1]...)
+		if function.Schema == "public" {
+			statementLines[0] = strings.Replace(statementLines[0], function.Schema+".", "", -1)
+		}
+		function.Statement = strings.Join(statementLines, "\n")
+
+		signature := function.Signature()
+		if _, ok := functions[signature]; ok {
+			return lines, functions, fmt.Errorf("storing functions - duplicate function %s", signature)
+		}
+		functions[signature] = function
+		i = j
+	}
+
+	return bufferLines, functions, nil
+}
+
+// sortFunctions returns the signatures of either functions or procedures in alphabetical order
+func sortFunctions(functions map[string]*Function, procedures bool) []string {
+	signatures := make([]string, 0, len(functions))
+	for signature, function := range functions {
+		if function.IsProcedure == procedures {
+			signatures = append(signatures, signature)
+		}
+	}
+	sort.Strings(signatures)
+	return signatures
+}
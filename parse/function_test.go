@@ -0,0 +1,265 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitArgument(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		expectedMode string
+		expectedName string
+		expectedType string
+	}{
+		{
+			name:         "Unnamed argument",
+			input:        "integer",
+			expectedMode: "IN",
+			expectedType: "integer",
+		},
+		{
+			name:         "Unnamed multi-word type",
+			input:        "timestamp(3) with time zone",
+			expectedMode: "IN",
+			expectedType: "timestamp(3) with time zone",
+		},
+		{
+			name:         "Named argument with default",
+			input:        "label character varying DEFAULT 'none'::character varying",
+			expectedMode: "IN",
+			expectedName: "label",
+			expectedType: "character varying",
+		},
+		{
+			name:         "Output argument",
+			input:        "OUT total numeric",
+			expectedMode: "OUT",
+			expectedName: "total",
+			expectedType: "numeric",
+		},
+		{
+			name:         "Variadic argument",
+			input:        "VARIADIC ids integer[]",
+			expectedMode: "VARIADIC",
+			expectedName: "ids",
+			expectedType: "integer[]",
+		},
+	}
+	for _, test := range tests {
+		mode, name, argType := splitArgument(test.input)
+		if mode != test.expectedMode || name != test.expectedName || argType != test.expectedType {
+			t.Error(test.name)
+		}
+	}
+}
+
+func TestStoreFunctions(t *testing.T) {
+	plpgsqlFunction := []string{
+		"CREATE FUNCTION public.set_updated_at() RETURNS trigger",
+		"    LANGUAGE plpgsql SECURITY DEFINER",
+		"    SET search_path TO 'public', 'pg_temp'",
+		"    AS $$",
+		"BEGIN",
+		"  NEW.updated_at = now();",
+		"  RETURN NEW;",
+		"END;",
+		"$$;",
+	}
+	overloadedFunctions := []string{
+		"CREATE OR REPLACE FUNCTION public.add(a integer, b integer) RETURNS integer",
+		"    LANGUAGE sql IMMUTABLE STRICT",
+		"    AS $_$select $1 + $2$_$;",
+		"CREATE FUNCTION public.add(a double precision, OUT total double precision) RETURNS double precision",
+		"    LANGUAGE sql",
+		"    AS $body$select a$body$;",
+	}
+	atomicFunction := []string{
+		"CREATE FUNCTION public.inc(a integer) RETURNS integer",
+		"    LANGUAGE sql",
+		"    BEGIN ATOMIC",
+		" SELECT (a + 1);",
+		"END;",
+	}
+	procedure := []string{
+		"CREATE PROCEDURE public.archive_orders(IN cutoff date, INOUT archived integer)",
+		"    LANGUAGE plpgsql",
+		"    AS $$",
+		"BEGIN",
+		"  DELETE FROM orders WHERE created_at < cutoff;",
+		"END;",
+		"$$;",
+	}
+	schemaFunctions := []string{
+		"CREATE FUNCTION public.touch() RETURNS trigger",
+		"    LANGUAGE sql",
+		"    AS $$select 1$$;",
+		"CREATE FUNCTION audit.touch() RETURNS trigger",
+		"    LANGUAGE sql",
+		"    AS $$select 1$$;",
+	}
+	expectedPlpgsqlFunction := &Function{
+		Name:       "set_updated_at",
+		Schema:     "public",
+		Returns:    "trigger",
+		Language:   "plpgsql",
+		Attributes: []string{"SECURITY DEFINER", "SET search_path TO 'public', 'pg_temp'"},
+		Body:       "\nBEGIN\n  NEW.updated_at = now();\n  RETURN NEW;\nEND;\n",
+		Statement: "CREATE FUNCTION set_updated_at() RETURNS trigger\n" +
+			"    LANGUAGE plpgsql SECURITY DEFINER\n" +
+			"    SET search_path TO 'public', 'pg_temp'\n" +
+			"    AS $$\nBEGIN\n  NEW.updated_at = now();\n  RETURN NEW;\nEND;\n$$;",
+	}
+	expectedIntegerFunction := &Function{
+		Name:        "add",
+		Schema:      "public",
+		Arguments:   []string{"a integer", "b integer"},
+		Returns:     "integer",
+		Language:    "sql",
+		Attributes:  []string{"IMMUTABLE", "STRICT"},
+		Body:        "select $1 + $2",
+		IsOrReplace: true,
+		Statement: "CREATE OR REPLACE FUNCTION add(a integer, b integer) RETURNS integer\n" +
+			"    LANGUAGE sql IMMUTABLE STRICT\n" +
+			"    AS $_$select $1 + $2$_$;",
+	}
+	expectedDoubleFunction := &Function{
+		Name:      "add",
+		Schema:    "public",
+		Arguments: []string{"a double precision", "OUT total double precision"},
+		Returns:   "double precision",
+		Language:  "sql",
+		Body:      "select a",
+		Statement: "CREATE FUNCTION add(a double precision, OUT total double precision) RETURNS double precision\n" +
+			"    LANGUAGE sql\n" +
+			"    AS $body$select a$body$;",
+	}
+	expectedAtomicFunction := &Function{
+		Name:      "inc",
+		Schema:    "public",
+		Arguments: []string{"a integer"},
+		Returns:   "integer",
+		Language:  "sql",
+		Body:      "SELECT (a + 1);",
+		IsAtomic:  true,
+		Statement: "CREATE FUNCTION inc(a integer) RETURNS integer\n    LANGUAGE sql\n    BEGIN ATOMIC\n SELECT (a + 1);\nEND;",
+	}
+	expectedProcedure := &Function{
+		Name:        "archive_orders",
+		Schema:      "public",
+		Arguments:   []string{"IN cutoff date", "INOUT archived integer"},
+		Language:    "plpgsql",
+		Body:        "\nBEGIN\n  DELETE FROM orders WHERE created_at < cutoff;\nEND;\n",
+		IsProcedure: true,
+		Statement: "CREATE PROCEDURE archive_orders(IN cutoff date, INOUT archived integer)\n" +
+			"    LANGUAGE plpgsql\n" +
+			"    AS $$\nBEGIN\n  DELETE FROM orders WHERE created_at < cutoff;\nEND;\n$$;",
+	}
+	expectedPublicFunction := &Function{
+		Name:      "touch",
+		Schema:    "public",
+		Returns:   "trigger",
+		Language:  "sql",
+		Body:      "select 1",
+		Statement: "CREATE FUNCTION touch() RETURNS trigger\n    LANGUAGE sql\n    AS $$select 1$$;",
+	}
+	expectedAuditFunction := &Function{
+		Name:      "touch",
+		Schema:    "audit",
+		Returns:   "trigger",
+		Language:  "sql",
+		Body:      "select 1",
+		Statement: "CREATE FUNCTION audit.touch() RETURNS trigger\n    LANGUAGE sql\n    AS $$select 1$$;",
+	}
+
+	tests := []struct {
+		name              string
+		input             []string
+		expectedFunctions map[string]*Function
+		expectedLines     []string
+		expectedError     error
+	}{
+		{
+			name:              "No input",
+			input:             []string{},
+			expectedFunctions: map[string]*Function{},
+			expectedLines:     []string{},
+			expectedError:     nil,
+		},
+		{
+			name:              "Function with attributes",
+			input:             plpgsqlFunction,
+			expectedFunctions: map[string]*Function{"set_updated_at()": expectedPlpgsqlFunction},
+			expectedLines:     []string{},
+			expectedError:     nil,
+		},
+		{
+			name:  "Overloaded functions",
+			input: overloadedFunctions,
+			expectedFunctions: map[string]*Function{
+				"add(integer, integer)": expectedIntegerFunction,
+				"add(double precision)": expectedDoubleFunction,
+			},
+			expectedLines: []string{},
+			expectedError: nil,
+		},
+		{
+			name:              "SQL-standard function body",
+			input:             atomicFunction,
+			expectedFunctions: map[string]*Function{"inc(integer)": expectedAtomicFunction},
+			expectedLines:     []string{},
+			expectedError:     nil,
+		},
+		{
+			name:              "Procedure",
+			input:             procedure,
+			expectedFunctions: map[string]*Function{"archive_orders(date, integer)": expectedProcedure},
+			expectedLines:     []string{},
+			expectedError:     nil,
+		},
+		{
+			name:  "Same-named functions in different schemas",
+			input: schemaFunctions,
+			expectedFunctions: map[string]*Function{
+				"touch()":       expectedPublicFunction,
+				"audit.touch()": expectedAuditFunction,
+			},
+			expectedLines: []string{},
+			expectedError: nil,
+		},
+		{
+			name:              "Function statements with extra lines",
+			input:             append(append([]string{"abc"}, atomicFunction...), "def"),
+			expectedFunctions: map[string]*Function{"inc(integer)": expectedAtomicFunction},
+			expectedLines:     []string{"abc", "def"},
+			expectedError:     nil,
+		},
+		{
+			name:              "Function is not terminated",
+			input:             plpgsqlFunction[:6],
+			expectedFunctions: map[string]*Function{},
+			expectedLines:     plpgsqlFunction[:6],
+			expectedError:     fmt.Errorf("storing functions - function is not terminated"),
+		},
+		{
+			name:              "Duplicate function",
+			input:             append(append([]string{}, atomicFunction...), atomicFunction...),
+			expectedFunctions: map[string]*Function{"inc(integer)": expectedAtomicFunction},
+			expectedLines:     append(append([]string{}, atomicFunction...), atomicFunction...),
+			expectedError:     fmt.Errorf("storing functions - duplicate function inc(integer)"),
+		},
+	}
+	for _, test := range tests {
+		lines, functions, err := StoreFunctions(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(functions, test.expectedFunctions) {
+			t.Error(test.name + " - functions error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package parse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Impact is an object referencing a column. Definite impacts are references known from the parsed schema, while
+// possible impacts are references found by scanning bodies and queries for the names of the column and its table.
+type Impact struct {
+	Kind     string
+	Object   string
+	Definite bool
+}
+
+func (i *Impact) String() string {
+	rank := "possible"
+	if i.Definite {
+		rank = "definite"
+	}
+	return fmt.Sprintf("%s %s %s", rank, i.Kind, i.Object)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// referencesColumn returns whether the sql expression references the column by name
+func referencesColumn(expr, columnName string) bool {
+	return references(expr)[columnName]&referenceName != 0
+}
+
+// tableImpacts returns the constraints, indexes, columns, statistics and rules of the table referencing its column
+func tableImpacts(tableName string, table *Table, columnName string) []*Impact {
+	var impacts []*Impact
+	column := table.Columns[columnName]
+	if _, rest := splitColumnStatement(column.Statement); strings.Contains(rest, "DEFAULT ") ||
+		strings.Contains(rest, "GENERATED ") {
+		impacts = append(impacts, &Impact{"default", tableName + "." + columnName, true})
+	}
+	for _, name := range sortedKeys(table.Columns) {
+		if name == columnName {
+			continue
+		}
+		if _, rest := splitColumnStatement(table.Columns[name].Statement); referencesColumn(rest, columnName) {
+			impacts = append(impacts, &Impact{"default", tableName + "." + name, true})
+		}
+	}
+
+	for _, name := range sortedKeys(table.Constraints) {
+		constraint := table.Constraints[name]
+		if !containsString(constraint.Columns, columnName) && !containsString(constraint.Include, columnName) &&
+			!referencesColumn(constraint.Expression+" "+constraint.Where, columnName) {
+			continue
+		}
+		kind := "constraint"
+		if constraint.Kind == ForeignKeyConstraint {
+			kind = "foreign key"
+		}
+		impacts = append(impacts, &Impact{kind, name + " on " + tableName, true})
+	}
+
+	for _, index := range table.Index {
+		referenced := containsString(index.Include, columnName) || referencesColumn(index.Where, columnName)
+		for _, key := range index.Keys {
+			referenced = referenced || referencesColumn(key.Expression, columnName)
+		}
+		if referenced {
+			impacts = append(impacts, &Impact{"index", index.Name + " on " + tableName, true})
+		}
+	}
+
+	for _, statistics := range table.Statistics {
+		if referencesColumn(strings.Join(statistics.Expressions, ", "), columnName) {
+			impacts = append(impacts, &Impact{"statistics", statistics.Name + " on " + tableName, true})
+		}
+	}
+	for _, rule := range table.Rules {
+		if referencesColumn(rule, columnName) {
+			impacts = append(impacts, &Impact{"rule", strings.Fields(rule)[2] + " on " + tableName, false})
+		}
+	}
+	return impacts
+}
+
+// ColumnImpact returns the objects referencing the column, given as table.column or schema.table.column, with the
+// definite impacts first. Foreign keys, constraints, indexes, defaults, statistics, publication column lists and
+// trigger columns reference the column definitely. Views, rules, triggers and function bodies mentioning the names
+// of the column and its table reference it possibly, or definitely where they qualify the column by its table and
+// are checked when created.
+func ColumnImpact(schema *Schema, column string) ([]*Impact, error) {
+	index := strings.LastIndex(column, ".")
+	if index == -1 {
+		return nil, fmt.Errorf("analysing impact - missing table of column %s", column)
+	}
+	tableName, _ := removeAccessModifier(column[:index])
+	columnName := column[index+1:]
+	table, ok := schema.Tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("analysing impact - table %s does not exist", tableName)
+	}
+	if _, ok := table.Columns[columnName]; !ok {
+		return nil, fmt.Errorf("analysing impact - column %s does not exist", column)
+	}
+
+	impacts := tableImpacts(tableName, table, columnName)
+
+	// foreign keys referencing the column
+	for _, name := range sortedKeys(schema.Tables) {
+		for _, constraintName := range sortedKeys(schema.Tables[name].Constraints) {
+			constraint := schema.Tables[name].Constraints[constraintName]
+			if constraint.Kind != ForeignKeyConstraint || constraint.RefTable != tableName {
+				continue
+			}
+			// foreign keys of the table itself holding the column are already listed
+			if name == tableName && containsString(constraint.Columns, columnName) {
+				continue
+			}
+			if containsString(referencedColumns(constraint, schema.Tables), columnName) {
+				impacts = append(impacts, &Impact{"foreign key", constraintName + " on " + name, true})
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(schema.Publications) {
+		for _, publicationTable := range schema.Publications[name].Tables {
+			if publicationTable.Name == tableName && (containsString(publicationTable.Columns, columnName) ||
+				referencesColumn(publicationTable.Where, columnName)) {
+				impacts = append(impacts, &Impact{"publication", name, true})
+			}
+		}
+	}
+
+	// mentions of the table and column in queries and bodies
+	mentions := func(expr string) (bool, bool) {
+		refs := references(expr)
+		qualified := refs[tableName+"."+columnName] != 0
+		return qualified, qualified || refs[tableName] != 0 && refs[columnName] != 0
+	}
+	for _, name := range sortedKeys(schema.Views) {
+		view := schema.Views[name]
+		if qualified, mentioned := mentions(view.Query); mentioned {
+			impacts = append(impacts, &Impact{"view", view.Name, qualified})
+		}
+	}
+	functionNames := make(map[string]bool)
+	for _, signature := range sortedKeys(schema.Functions) {
+		function := schema.Functions[signature]
+		if qualified, mentioned := mentions(function.Body); mentioned {
+			kind := "function"
+			if function.IsProcedure {
+				kind = "procedure"
+			}
+			// only standard sql bodies are checked when created
+			impacts = append(impacts, &Impact{kind, signature, qualified && function.IsAtomic})
+			functionNames[function.Name] = true
+		}
+	}
+	for _, trigger := range schema.Triggers {
+		if trigger.Table != tableName {
+			continue
+		}
+		object := trigger.Name + " on " + tableName
+		// UPDATE OF column lists and WHEN conditions reference the columns of the table
+		if updateOf := strings.Index(trigger.Statement, " UPDATE OF "); updateOf != -1 {
+			end := strings.Index(trigger.Statement[updateOf:], " ON ") + updateOf
+			if referencesColumn(trigger.Statement[updateOf:end], columnName) {
+				impacts = append(impacts, &Impact{"trigger", object, true})
+				continue
+			}
+		}
+		if when := strings.Index(trigger.Statement, " WHEN "); when != -1 &&
+			referencesColumn(trigger.Statement[when:], columnName) {
+			impacts = append(impacts, &Impact{"trigger", object, true})
+		} else if functionNames[triggerFunction(trigger.Statement)] {
+			impacts = append(impacts, &Impact{"trigger", object, false})
+		} else if function, ok := schema.Functions[triggerFunction(trigger.Statement)+"()"]; ok &&
+			referencesColumn(function.Body, columnName) {
+			// trigger functions reference the columns of their tables through NEW and OLD
+			impacts = append(impacts, &Impact{"trigger", object, false})
+		}
+	}
+
+	sort.SliceStable(impacts, func(i, j int) bool {
+		return impacts[i].Definite && !impacts[j].Definite
+	})
+	return impacts, nil
+}
@@ -0,0 +1,88 @@
+package parse
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestColumnImpact(t *testing.T) {
+	input := strings.Join([]string{
+		"CREATE FUNCTION public.touch() RETURNS trigger\n    LANGUAGE plpgsql\n    AS $$\nBEGIN\n" +
+			"  NEW.user_id := NEW.user_id;\n  RETURN NEW;\nEND;\n$$;",
+		"CREATE FUNCTION public.count_orders(uid integer) RETURNS bigint\n    LANGUAGE sql\n" +
+			"    AS $$ SELECT count(*) FROM orders WHERE user_id = uid $$;",
+		"CREATE VIEW public.user_orders AS\n SELECT orders.id,\n    orders.user_id\n   FROM public.orders;",
+		"CREATE VIEW public.order_ids AS\n SELECT orders.id\n   FROM public.orders;",
+		"CREATE TABLE public.users (\n    id integer NOT NULL,\n    parent_id integer\n);",
+		"CREATE TABLE public.orders (\n    id integer NOT NULL,\n    user_id integer DEFAULT 0,\n" +
+			"    owner_id integer GENERATED ALWAYS AS ((user_id + 1)) STORED\n);",
+		"ALTER TABLE ONLY public.users\n    ADD CONSTRAINT users_pkey PRIMARY KEY (id);",
+		"ALTER TABLE ONLY public.users\n    ADD CONSTRAINT users_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES public.users;",
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_user_id_check CHECK ((user_id > 0));",
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);",
+		"CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id) WHERE (id > 0);",
+		"CREATE TRIGGER orders_touch BEFORE UPDATE OF user_id ON public.orders FOR EACH ROW " +
+			"EXECUTE FUNCTION public.touch();",
+	}, "\n\n") + "\n"
+	_, schema, err := ReadSchema(bufio.NewReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		column          string
+		expectedImpacts []string
+		hasError        bool
+	}{
+		{
+			name:   "Referencing column",
+			column: "orders.user_id",
+			expectedImpacts: []string{
+				"definite default orders.user_id",
+				"definite default orders.owner_id",
+				"definite constraint orders_user_id_check on orders",
+				"definite foreign key orders_user_id_fkey on orders",
+				"definite index orders_user_id_idx on orders",
+				"definite view user_orders",
+				"definite trigger orders_touch on orders",
+				"possible function count_orders(integer)",
+			},
+		},
+		{
+			name:   "Referenced column",
+			column: "public.users.id",
+			expectedImpacts: []string{
+				"definite constraint users_pkey on users",
+				"definite foreign key orders_user_id_fkey on orders",
+				"definite foreign key users_parent_id_fkey on users",
+			},
+		},
+		{name: "Missing table of column", column: "user_id", hasError: true},
+		{name: "Missing table", column: "missing.id", hasError: true},
+		{name: "Missing column", column: "orders.missing", hasError: true},
+	}
+	for _, test := range tests {
+		impacts, err := ColumnImpact(schema, test.column)
+		if test.hasError {
+			if err == nil {
+				t.Error(test.name + " - error expected")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(test.name + " - unexpected error")
+			continue
+		}
+		var impactStrings []string
+		for _, impact := range impacts {
+			impactStrings = append(impactStrings, impact.String())
+		}
+		if !cmp.Equal(impactStrings, test.expectedImpacts) {
+			t.Error(test.name + " - impacts error")
+		}
+	}
+}
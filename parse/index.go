@@ -0,0 +1,218 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IndexKey is the struct containing logical aspects of an index key column or expression
+type IndexKey struct {
+	Expression string
+	Collation  string
+	OpClass    string
+	Order      string
+	Nulls      string
+}
+
+// Index is the struct containing logical aspects of an index definition
+type Index struct {
+	Name             string
+	Table            string
+	IsUnique         bool
+	IsOnly           bool
+	Method           string
+	Keys             []*IndexKey
+	Include          []string
+	NullsNotDistinct bool
+	Parameters       []string
+	Tablespace       string
+	Where            string
+	Partitions       []string
+}
+
+// defaultIndexMethod is the access method used by indexes which do not specify one
+const defaultIndexMethod = "btree"
+
+// String returns the key as it appears in an index definition
+func (k *IndexKey) String() string {
+	parts := []string{k.Expression}
+	if len(k.Collation) > 0 {
+		parts = append(parts, "COLLATE "+k.Collation)
+	}
+	for _, part := range []string{k.OpClass, k.Order} {
+		if len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	if len(k.Nulls) > 0 {
+		parts = append(parts, "NULLS "+k.Nulls)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Statement returns the create statement of the index
+func (i *Index) Statement() string {
+	stmt := "CREATE "
+	if i.IsUnique {
+		stmt += "UNIQUE "
+	}
+	stmt += "INDEX " + i.Name + " ON "
+	if i.IsOnly {
+		stmt += "ONLY "
+	}
+	keys := make([]string, len(i.Keys))
+	for j, key := range i.Keys {
+		keys[j] = key.String()
+	}
+	stmt += i.Table + " USING " + i.Method + " (" + strings.Join(keys, ", ") + ")"
+	if len(i.Include) > 0 {
+		stmt += " INCLUDE (" + strings.Join(i.Include, ", ") + ")"
+	}
+	if i.NullsNotDistinct {
+		stmt += " NULLS NOT DISTINCT"
+	}
+	if len(i.Parameters) > 0 {
+		stmt += " WITH (" + strings.Join(i.Parameters, ", ") + ")"
+	}
+	if len(i.Tablespace) > 0 {
+		stmt += " TABLESPACE " + i.Tablespace
+	}
+	if len(i.Where) > 0 {
+		stmt += " WHERE " + i.Where
+	}
+	return stmt + ";"
+}
+
+// AttachStatements returns the statements attaching the indexes of partitions to the index
+func (i *Index) AttachStatements() []string {
+	stmts := make([]string, len(i.Partitions))
+	for j, partition := range i.Partitions {
+		stmts[j] = "ALTER INDEX " + i.Name + " ATTACH PARTITION " + partition + ";"
+	}
+	return stmts
+}
+
+// parseIndexKey parses an element of the key list of an index definition
+func parseIndexKey(element string) *IndexKey {
+	tokens := tokenize(element)
+	key := &IndexKey{Expression: tokens[0]}
+	for i := 1; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "COLLATE":
+			if i+1 < len(tokens) {
+				key.Collation = tokens[i+1]
+				i++
+			}
+		case "ASC", "DESC":
+			key.Order = tokens[i]
+		case "NULLS":
+			if i+1 < len(tokens) {
+				key.Nulls = tokens[i+1]
+				i++
+			}
+		default:
+			// operator classes may be followed by their parameters
+			if len(key.OpClass) > 0 {
+				key.OpClass += " " + tokens[i]
+			} else {
+				key.OpClass = tokens[i]
+			}
+		}
+	}
+	return key
+}
+
+// parseIndex parses a create index statement into an Index
+func parseIndex(stmt string) (*Index, error) {
+	tokens := tokenize(strings.TrimSuffix(stmt, ";"))
+	index := &Index{Method: defaultIndexMethod}
+
+	i := 1
+	if i < len(tokens) && tokens[i] == "UNIQUE" {
+		index.IsUnique = true
+		i++
+	}
+	if i >= len(tokens) || tokens[i] != "INDEX" {
+		return nil, fmt.Errorf("invalid index")
+	}
+	i++
+	for i < len(tokens) && (tokens[i] == "CONCURRENTLY" || tokens[i] == "IF" || tokens[i] == "NOT" ||
+		tokens[i] == "EXISTS") {
+		i++
+	}
+	if i < len(tokens) && tokens[i] != "ON" {
+		index.Name = tokens[i]
+		i++
+	}
+	if i >= len(tokens) || tokens[i] != "ON" {
+		return nil, fmt.Errorf("invalid index")
+	}
+	i++
+	if i < len(tokens) && tokens[i] == "ONLY" {
+		index.IsOnly = true
+		i++
+	}
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("invalid index")
+	}
+	var modifier string
+	index.Table, modifier = removeAccessModifier(tokens[i])
+	if len(modifier) > 0 {
+		// keys, covered columns and predicates are qualified by the schema of the table as well
+		tokens = tokenize(strings.Replace(strings.TrimSuffix(stmt, ";"), modifier+".", "", -1))
+	}
+	i++
+	if i+1 < len(tokens) && tokens[i] == "USING" {
+		index.Method = tokens[i+1]
+		i += 2
+	}
+	if i >= len(tokens) || !strings.HasPrefix(tokens[i], "(") {
+		return nil, fmt.Errorf("invalid index")
+	}
+	for _, element := range parenList(tokens[i]) {
+		index.Keys = append(index.Keys, parseIndexKey(element))
+	}
+	i++
+
+	for ; i < len(tokens); i++ {
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch tokens[i] {
+		case "INCLUDE":
+			index.Include = parenList(next)
+			i++
+		case "NULLS":
+			// NULLS DISTINCT is the default
+			if next == "NOT" {
+				index.NullsNotDistinct = true
+				i++
+			}
+			i++
+		case "WITH":
+			index.Parameters = parenList(next)
+			i++
+		case "TABLESPACE":
+			index.Tablespace = next
+			i++
+		case "WHERE":
+			index.Where = strings.Join(tokens[i+1:], " ")
+			i = len(tokens)
+		}
+	}
+
+	return index, nil
+}
+
+// findIndex returns the index of tables with the given name, or nil if there is none
+func findIndex(tables map[string]*Table, name string) *Index {
+	for _, table := range tables {
+		for _, index := range table.Index {
+			if index.Name == name {
+				return index
+			}
+		}
+	}
+	return nil
+}
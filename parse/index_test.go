@@ -0,0 +1,114 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseIndex(t *testing.T) {
+	tests := []struct {
+		name              string
+		input             string
+		expectedIndex     *Index
+		expectedStatement string
+		expectedError     error
+	}{
+		{
+			name:          "Invalid index",
+			input:         "CREATE INDEX broken ON public.users;",
+			expectedIndex: nil,
+			expectedError: fmt.Errorf("invalid index"),
+		},
+		{
+			name:  "Expression index with operator classes and sort order",
+			input: "CREATE INDEX users_email_idx ON public.users USING btree (lower(email) text_pattern_ops, created_at DESC NULLS LAST);",
+			expectedIndex: &Index{
+				Name:   "users_email_idx",
+				Table:  "users",
+				Method: "btree",
+				Keys: []*IndexKey{
+					{Expression: "lower(email)", OpClass: "text_pattern_ops"},
+					{Expression: "created_at", Order: "DESC", Nulls: "LAST"},
+				},
+			},
+			expectedStatement: "CREATE INDEX users_email_idx ON users USING btree (lower(email) text_pattern_ops, created_at DESC NULLS LAST);",
+			expectedError:     nil,
+		},
+		{
+			name:  "Partial covering unique index",
+			input: "CREATE UNIQUE INDEX users_name_key ON public.users USING btree (name COLLATE \"C\") INCLUDE (email) NULLS NOT DISTINCT WITH (fillfactor='90') WHERE (deleted_at IS NULL);",
+			expectedIndex: &Index{
+				Name:             "users_name_key",
+				Table:            "users",
+				IsUnique:         true,
+				Method:           "btree",
+				Keys:             []*IndexKey{{Expression: "name", Collation: "\"C\""}},
+				Include:          []string{"email"},
+				NullsNotDistinct: true,
+				Parameters:       []string{"fillfactor='90'"},
+				Where:            "(deleted_at IS NULL)",
+			},
+			expectedStatement: "CREATE UNIQUE INDEX users_name_key ON users USING btree (name COLLATE \"C\") INCLUDE (email) NULLS NOT DISTINCT WITH (fillfactor='90') WHERE (deleted_at IS NULL);",
+			expectedError:     nil,
+		},
+		{
+			name:  "Index with qualified expressions and distinct nulls",
+			input: "CREATE UNIQUE INDEX users_f_key ON public.users USING btree ((public.f(a))) NULLS DISTINCT WHERE (public.g(b));",
+			expectedIndex: &Index{
+				Name:     "users_f_key",
+				Table:    "users",
+				IsUnique: true,
+				Method:   "btree",
+				Keys:     []*IndexKey{{Expression: "(f(a))"}},
+				Where:    "(g(b))",
+			},
+			expectedStatement: "CREATE UNIQUE INDEX users_f_key ON users USING btree ((f(a))) WHERE (g(b));",
+			expectedError:     nil,
+		},
+		{
+			name:  "Index on partitioned table",
+			input: "CREATE INDEX events_payload_idx ON ONLY public.events USING gin (payload jsonb_path_ops);",
+			expectedIndex: &Index{
+				Name:   "events_payload_idx",
+				Table:  "events",
+				IsOnly: true,
+				Method: "gin",
+				Keys:   []*IndexKey{{Expression: "payload", OpClass: "jsonb_path_ops"}},
+			},
+			expectedStatement: "CREATE INDEX events_payload_idx ON ONLY events USING gin (payload jsonb_path_ops);",
+			expectedError:     nil,
+		},
+	}
+	for _, test := range tests {
+		index, err := parseIndex(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(index, test.expectedIndex) {
+			t.Error(test.name + " - index error")
+		} else if index != nil && index.Statement() != test.expectedStatement {
+			t.Error(test.name + " - statement error")
+		}
+	}
+}
+
+func TestMapIndicesAttachPartition(t *testing.T) {
+	tables := map[string]*Table{"events": {}, "events_2024": {}}
+	lines, err := MapIndices([]string{
+		"CREATE INDEX events_created_idx ON ONLY public.events USING btree (created_at);",
+		"CREATE INDEX events_2024_created_idx ON public.events_2024 USING btree (created_at);",
+		"ALTER INDEX public.events_created_idx ATTACH PARTITION public.events_2024_created_idx;",
+	}, tables)
+	if err != nil || len(lines) != 0 {
+		t.Error("Attach partition - fatal error")
+	} else if stmts := tables["events"].Index[0].AttachStatements(); !cmp.Equal(stmts,
+		[]string{"ALTER INDEX events_created_idx ATTACH PARTITION events_2024_created_idx;"}) {
+		t.Error("Attach partition - statements error")
+	}
+
+	_, err = MapIndices([]string{"ALTER INDEX public.missing_idx ATTACH PARTITION public.events_2024_created_idx;"}, tables)
+	if err == nil || err.Error() != "mapping indices - index does not exist" {
+		t.Error("Attach partition - index does not exist error")
+	}
+}
@@ -0,0 +1,135 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Join is a step of a join path, joining a table to the previous table of the path by a foreign key of either table.
+// IsReferencing is set if the foreign key belongs to the joined table.
+type Join struct {
+	Table         string
+	Columns       []string
+	PrevTable     string
+	PrevColumns   []string
+	Constraint    string
+	IsReferencing bool
+}
+
+// On returns the condition joining the table to the previous table
+func (j *Join) On() string {
+	conditions := make([]string, len(j.Columns))
+	for i := range j.Columns {
+		conditions[i] = fmt.Sprintf("%s.%s = %s.%s", j.Table, j.Columns[i], j.PrevTable, j.PrevColumns[i])
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// referencedColumns returns the columns referenced by the foreign key, which are the primary key columns of the
+// referenced table if the foreign key does not list them
+func referencedColumns(constraint *Constraint, tables map[string]*Table) []string {
+	if len(constraint.RefColumns) > 0 {
+		return constraint.RefColumns
+	}
+	for _, name := range sortedKeys(tables[constraint.RefTable].Constraints) {
+		if refConstraint := tables[constraint.RefTable].Constraints[name]; refConstraint.Kind == PrimaryKeyConstraint {
+			return refConstraint.Columns
+		}
+	}
+	return nil
+}
+
+// joins returns the joins from one table to another by the foreign keys between them in either direction
+func joins(prevTable, table string, tables map[string]*Table) []*Join {
+	var joins []*Join
+	for _, name := range sortedKeys(tables[table].Constraints) {
+		constraint := tables[table].Constraints[name]
+		if constraint.Kind == ForeignKeyConstraint && constraint.RefTable == prevTable {
+			joins = append(joins, &Join{
+				Table:         table,
+				Columns:       constraint.Columns,
+				PrevTable:     prevTable,
+				PrevColumns:   referencedColumns(constraint, tables),
+				Constraint:    name,
+				IsReferencing: true,
+			})
+		}
+	}
+	for _, name := range sortedKeys(tables[prevTable].Constraints) {
+		constraint := tables[prevTable].Constraints[name]
+		if constraint.Kind == ForeignKeyConstraint && constraint.RefTable == table {
+			joins = append(joins, &Join{
+				Table:       table,
+				Columns:     referencedColumns(constraint, tables),
+				PrevTable:   prevTable,
+				PrevColumns: constraint.Columns,
+				Constraint:  name,
+			})
+		}
+	}
+	return joins
+}
+
+// JoinPath is a path of joins from a table
+type JoinPath struct {
+	From  string
+	Joins []*Join
+}
+
+// JoinPaths returns the shortest join paths from one table to another following foreign keys in either direction.
+// Tables joined by several foreign keys give a path for each foreign key.
+func JoinPaths(tables map[string]*Table, from, to string) ([]*JoinPath, error) {
+	from, _ = removeAccessModifier(from)
+	to, _ = removeAccessModifier(to)
+	for _, name := range []string{from, to} {
+		if _, ok := tables[name]; !ok {
+			return nil, fmt.Errorf("finding join paths - table %s does not exist", name)
+		}
+	}
+
+	tablePaths := tableGraph(tables).ShortestPaths(from, to, true)
+	if len(tablePaths) == 0 {
+		return nil, fmt.Errorf("finding join paths - no path between %s and %s", from, to)
+	}
+
+	var paths []*JoinPath
+	for _, tablePath := range tablePaths {
+		expanded := [][]*Join{{}}
+		for i := 1; i < len(tablePath); i++ {
+			var next [][]*Join
+			for _, path := range expanded {
+				for _, join := range joins(tablePath[i-1], tablePath[i], tables) {
+					next = append(next, append(append([]*Join{}, path...), join))
+				}
+			}
+			expanded = next
+		}
+		for _, joins := range expanded {
+			paths = append(paths, &JoinPath{From: from, Joins: joins})
+		}
+	}
+	return paths, nil
+}
+
+// String returns the tables of the join path and the foreign keys joining them, with arrows pointing from the tables
+// of the foreign keys to the tables they reference
+func (p *JoinPath) String() string {
+	s := p.From
+	for _, join := range p.Joins {
+		if join.IsReferencing {
+			s += fmt.Sprintf(" <-[%s]- %s", join.Constraint, join.Table)
+		} else {
+			s += fmt.Sprintf(" -[%s]-> %s", join.Constraint, join.Table)
+		}
+	}
+	return s
+}
+
+// Select returns a select statement joining the tables of the join path
+func (p *JoinPath) Select() string {
+	lines := []string{"SELECT *", "FROM " + p.From}
+	for _, join := range p.Joins {
+		lines = append(lines, fmt.Sprintf("JOIN %s ON %s", join.Table, join.On()))
+	}
+	return strings.Join(lines, "\n") + ";"
+}
@@ -0,0 +1,82 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJoinPaths(t *testing.T) {
+	tables := map[string]*Table{
+		"users": {Constraints: map[string]*Constraint{
+			"users_pkey": {Kind: PrimaryKeyConstraint, Columns: []string{"id"}},
+		}},
+		"orders": {Constraints: map[string]*Constraint{
+			"orders_buyer_id_fkey": {Kind: ForeignKeyConstraint, Columns: []string{"buyer_id"}, RefTable: "users",
+				RefColumns: []string{"id"}},
+			"orders_seller_id_fkey": {Kind: ForeignKeyConstraint, Columns: []string{"seller_id"}, RefTable: "users"},
+		}},
+		"items": {Constraints: map[string]*Constraint{
+			"items_order_fkey": {Kind: ForeignKeyConstraint, Columns: []string{"order_id", "order_date"},
+				RefTable: "orders", RefColumns: []string{"id", "date"}},
+		}},
+		"other": {},
+	}
+
+	tests := []struct {
+		name            string
+		from            string
+		to              string
+		expectedPaths   []string
+		expectedSelects []string
+		hasError        bool
+	}{
+		{
+			name: "Referenced direction",
+			from: "items",
+			to:   "public.users",
+			expectedPaths: []string{
+				"items -[items_order_fkey]-> orders -[orders_buyer_id_fkey]-> users",
+				"items -[items_order_fkey]-> orders -[orders_seller_id_fkey]-> users",
+			},
+			expectedSelects: []string{
+				"SELECT *\nFROM items\nJOIN orders ON orders.id = items.order_id AND orders.date = items.order_date\n" +
+					"JOIN users ON users.id = orders.buyer_id;",
+				"SELECT *\nFROM items\nJOIN orders ON orders.id = items.order_id AND orders.date = items.order_date\n" +
+					"JOIN users ON users.id = orders.seller_id;",
+			},
+		},
+		{
+			name:            "Referencing direction",
+			from:            "orders",
+			to:              "items",
+			expectedPaths:   []string{"orders <-[items_order_fkey]- items"},
+			expectedSelects: []string{"SELECT *\nFROM orders\nJOIN items ON items.order_id = orders.id AND items.order_date = orders.date;"},
+		},
+		{name: "Missing table", from: "orders", to: "missing", hasError: true},
+		{name: "No path", from: "orders", to: "other", hasError: true},
+	}
+	for _, test := range tests {
+		paths, err := JoinPaths(tables, test.from, test.to)
+		if test.hasError {
+			if err == nil {
+				t.Error(test.name + " - error expected")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(test.name + " - unexpected error")
+			continue
+		}
+		var pathStrings, selects []string
+		for _, path := range paths {
+			pathStrings = append(pathStrings, path.String())
+			selects = append(selects, path.Select())
+		}
+		if !cmp.Equal(pathStrings, test.expectedPaths) {
+			t.Error(test.name + " - paths error")
+		} else if !cmp.Equal(selects, test.expectedSelects) {
+			t.Error(test.name + " - selects error")
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jchiam/psql-schema-dump-sanitiser/graph"
+)
+
+// Ways destructive statements are handled in migrations
+const (
+	DestructiveAllow   = "allow"
+	DestructiveComment = "comment"
+	DestructiveRefuse  = "refuse"
+)
+
+// MigrateOptions holds the options controlling how migrations are generated
+type MigrateOptions struct {
+	Destructive     string
+	ShowCredentials bool
+}
+
+// migrationKinds are the kinds of objects in the order they are created when their dependencies allow
+var migrationKinds = []string{
+	KindCollation, KindDictionary, KindConfiguration, KindType, KindDomain, KindSequence, KindTable, KindFunction,
+	KindProcedure, KindAggregate, KindOperator, KindCast, KindWrapper, KindServer, KindUserMapping, KindForeignTable,
+	KindView, KindPublication, KindSubscription, KindEventTrigger, KindTrigger,
+}
+
+// migrationStatement is a statement of a migration. Destructive statements lose data.
+type migrationStatement struct {
+	stmt        string
+	destructive bool
+}
+
+// migration holds the statements of a migration. Foreign keys are dropped before and added after the statements of
+// other objects, which are dropped in reverse dependency order of the old schema and created in dependency order of
+// the new schema, by the ids of the objects they belong to.
+type migration struct {
+	before  []*migrationStatement
+	drops   map[string][]*migrationStatement
+	creates map[string][]*migrationStatement
+	after   []*migrationStatement
+}
+
+func (m *migration) drop(id string, destructive bool, stmts ...string) {
+	for _, stmt := range stmts {
+		m.drops[id] = append(m.drops[id], &migrationStatement{stmt, destructive})
+	}
+}
+
+func (m *migration) create(id string, stmts ...string) {
+	for _, stmt := range stmts {
+		m.creates[id] = append(m.creates[id], &migrationStatement{stmt, false})
+	}
+}
+
+// byHand returns a comment noting a change which must be migrated by hand
+func byHand(format string, a ...any) string {
+	return "-- " + fmt.Sprintf(format, a...) + " and must be migrated by hand"
+}
+
+// dropStatement returns the statement dropping the object of the kind from the schema
+func dropStatement(schema *Schema, kind, key string) string {
+	switch kind {
+	case KindDictionary:
+		return "DROP TEXT SEARCH DICTIONARY " + key + ";"
+	case KindConfiguration:
+		return "DROP TEXT SEARCH CONFIGURATION " + key + ";"
+	case KindWrapper:
+		return "DROP FOREIGN DATA WRAPPER " + key + ";"
+	case KindUserMapping:
+		return "DROP USER MAPPING FOR " + schema.UserMappings[key].User + " SERVER " + schema.UserMappings[key].Server +
+			";"
+	case KindForeignTable:
+		return "DROP FOREIGN TABLE " + key + ";"
+	case KindView:
+		if schema.Views[key].IsMaterialized {
+			return "DROP MATERIALIZED VIEW " + key + ";"
+		}
+	case KindEventTrigger:
+		return "DROP EVENT TRIGGER " + key + ";"
+	case KindTrigger:
+		index := strings.Index(key, ".")
+		return "DROP TRIGGER " + key[index+1:] + " ON " + key[:index] + ";"
+	}
+	// collations, types, domains, sequences, tables, functions, procedures, aggregates, operators, casts, servers,
+	// views, publications and subscriptions are dropped by their names or signatures
+	return "DROP " + strings.ToUpper(kind) + " " + key + ";"
+}
+
+// alterSequence returns the statement altering a sequence to the options of its create statement
+func alterSequence(stmt string) string {
+	return strings.Replace(stmt, "CREATE SEQUENCE ", "ALTER SEQUENCE ", 1)
+}
+
+// alterType returns the statements adding the labels appended to an enum type. Other changes of types cannot be
+// migrated while columns use the type, so they are noted in a comment.
+func alterType(name string, from, to *Type) []string {
+	fromLabels, toLabels := from.Labels(), to.Labels()
+	if from.Kind != EnumType || to.Kind != EnumType || len(toLabels) <= len(fromLabels) ||
+		!cmp.Equal(fromLabels, toLabels[:len(fromLabels)]) {
+		return []string{byHand("type %s changed from %q to %q", name, from.Statement, to.Statement)}
+	}
+	var stmts []string
+	for _, label := range toLabels[len(fromLabels):] {
+		stmts = append(stmts, "ALTER TYPE "+name+" ADD VALUE "+label+";")
+	}
+	return stmts
+}
+
+// columnUses returns the ids of the objects used by the columns of the old schema which are kept in the new schema.
+// Such objects cannot be dropped without dropping or converting the columns.
+func columnUses(from, to *Schema) map[string]bool {
+	d := newDependencyIndex(from)
+	g := graph.New()
+	for tableName, table := range from.Tables {
+		if _, ok := to.Tables[tableName]; !ok {
+			continue
+		}
+		for columnName, column := range table.Columns {
+			if _, ok := to.Tables[tableName].Columns[columnName]; ok {
+				d.addReferences(g, objectID(KindTable, tableName), column.Statement, false)
+			}
+		}
+	}
+	uses := make(map[string]bool)
+	for _, id := range g.Nodes() {
+		if objectKind(id) != KindTable {
+			uses[id] = true
+		}
+	}
+	return uses
+}
+
+// orReplace returns the create statement of the function replacing any existing definition
+func orReplace(function *Function) string {
+	if function.IsOrReplace {
+		return function.Statement
+	}
+	return strings.Replace(function.Statement, "CREATE ", "CREATE OR REPLACE ", 1)
+}
+
+// parameterChanges returns the statements resetting the removed parameters and setting the changed parameters, each
+// prefixed by prefix
+func parameterChanges(prefix string, from, to []string) []string {
+	var stmts, reset, set []string
+	names := make(map[string]bool)
+	for _, parameter := range to {
+		names[parameterName(parameter)] = true
+	}
+	for _, parameter := range from {
+		if !names[parameterName(parameter)] {
+			reset = append(reset, parameterName(parameter))
+		}
+	}
+	for _, parameter := range to {
+		if !containsString(from, parameter) {
+			set = append(set, parameter)
+		}
+	}
+	if len(reset) > 0 {
+		stmts = append(stmts, prefix+" RESET ("+strings.Join(reset, ", ")+");")
+	}
+	if len(set) > 0 {
+		stmts = append(stmts, prefix+" SET ("+strings.Join(set, ", ")+");")
+	}
+	return stmts
+}
+
+// columnStatements returns the statements migrating the changed attribute of a column
+func columnStatements(tableName, columnName string, change *Change) []string {
+	prefix := "ALTER TABLE " + tableName + " ALTER COLUMN " + columnName
+	switch change.Attribute {
+	case "type":
+		return []string{prefix + " TYPE " + change.New + ";"}
+	case "default":
+		if len(change.New) == 0 {
+			return []string{prefix + " DROP DEFAULT;"}
+		}
+		return []string{prefix + " SET DEFAULT " + change.New + ";"}
+	case "nullability":
+		if change.New == "NOT NULL" {
+			return []string{prefix + " SET NOT NULL;"}
+		}
+		return []string{prefix + " DROP NOT NULL;"}
+	case "statistics":
+		if len(change.New) == 0 {
+			return []string{prefix + " SET STATISTICS -1;"}
+		}
+		return []string{prefix + " SET STATISTICS " + change.New + ";"}
+	case "storage":
+		if len(change.New) > 0 {
+			return []string{prefix + " SET STORAGE " + change.New + ";"}
+		}
+	case "options":
+		return parameterChanges(prefix, splitTopLevel(change.Old, ','), splitTopLevel(change.New, ','))
+	}
+	return []string{byHand("column %s.%s %s changed from %q to %q", tableName, columnName, change.Attribute,
+		change.Old, change.New)}
+}
+
+// migrateTable adds the statements migrating a table present in both schemas to the migration, returning whether
+// columns of the table are dropped or retyped
+func migrateTable(m *migration, tableName string, from, to *Table) bool {
+	if from.IsDeepEqual(to) {
+		return false
+	}
+	id := objectID(KindTable, tableName)
+	prefix := "ALTER TABLE " + tableName
+	fromParts, toParts := tableParts(tableName, from), tableParts(tableName, to)
+	changes := make(map[string][]*Change)
+	for kind := range fromParts {
+		changes[kind] = diffDefinitions(kind, "definition", fromParts[kind], toParts[kind])
+	}
+	altered := false
+
+	// drop the parts removed or changed in reverse order of creation
+	for _, change := range changes[KindRule] {
+		if change.Action != ActionAdded {
+			m.drop(id, false, "DROP RULE "+change.Object+" ON "+tableName+";")
+		}
+	}
+	for _, change := range changes[KindStatistics] {
+		if change.Action != ActionAdded {
+			m.drop(id, false, "DROP STATISTICS "+change.Object+";")
+		}
+	}
+	for _, change := range changes[KindIndex] {
+		if change.Action != ActionAdded {
+			m.drop(id, false, "DROP INDEX "+change.Object+";")
+		}
+	}
+	for _, change := range changes[KindConstraint] {
+		if change.Action == ActionAdded {
+			continue
+		}
+		stmt := prefix + " DROP CONSTRAINT " + change.Object + ";"
+		if from.Constraints[change.Object].Kind == ForeignKeyConstraint {
+			m.before = append(m.before, &migrationStatement{stmt, false})
+		} else {
+			m.drop(id, false, stmt)
+		}
+	}
+	for _, change := range changes[KindColumn] {
+		if change.Action == ActionRemoved {
+			m.drop(id, true, prefix+" DROP COLUMN "+change.Object+";")
+			altered = true
+		}
+	}
+	// defaults are dropped ahead of the sequences and functions they may use
+	for _, name := range sortedKeys(to.Columns) {
+		if column, ok := from.Columns[name]; ok {
+			for _, change := range diffColumn(name, column, to.Columns[name]) {
+				if change.Attribute == "default" && len(change.New) == 0 {
+					m.drop(id, false, columnStatements(tableName, name, change)...)
+				}
+			}
+		}
+	}
+	for _, change := range changes[KindSequence] {
+		if change.Action == ActionRemoved {
+			// sequences owned by dropped columns are dropped with them
+			m.drop(id, true, "DROP SEQUENCE IF EXISTS "+change.Object+";")
+		}
+	}
+
+	// alter the table and create the parts added or changed
+	attributes := [][3]string{
+		{"clauses", strings.Join(from.Clauses, " "), strings.Join(to.Clauses, " ")},
+		{"cluster on", from.ClusterOn, to.ClusterOn},
+		{"replica identity", from.ReplicaIdentity, to.ReplicaIdentity},
+	}
+	m.create(id, parameterChanges(prefix, from.StorageParameters, to.StorageParameters)...)
+	for _, attribute := range attributes {
+		if attribute[1] == attribute[2] {
+			continue
+		}
+		switch {
+		case attribute[0] == "cluster on" && len(attribute[2]) > 0:
+			m.create(id, prefix+" CLUSTER ON "+attribute[2]+";")
+		case attribute[0] == "cluster on":
+			m.create(id, prefix+" SET WITHOUT CLUSTER;")
+		case attribute[0] == "replica identity" && len(attribute[2]) > 0:
+			m.create(id, "ALTER TABLE ONLY "+tableName+" REPLICA IDENTITY "+attribute[2]+";")
+		case attribute[0] == "replica identity":
+			m.create(id, "ALTER TABLE ONLY "+tableName+" REPLICA IDENTITY DEFAULT;")
+		default:
+			m.create(id, byHand("table %s %s changed from %q to %q", tableName, attribute[0], attribute[1],
+				attribute[2]))
+		}
+	}
+	for _, change := range changes[KindSequence] {
+		if change.Action == ActionAdded {
+			m.create(id, strings.Split(change.New, "\n")[0])
+		} else if change.Action == ActionModified {
+			m.create(id, alterSequence(strings.Split(change.New, "\n")[0]))
+		}
+	}
+	for _, change := range changes[KindColumn] {
+		if change.Action == ActionAdded {
+			m.create(id, prefix+" ADD COLUMN "+change.Object+" "+change.New+";")
+		}
+	}
+	for _, name := range sortedKeys(to.Columns) {
+		if column, ok := from.Columns[name]; ok {
+			for _, change := range diffColumn(name, column, to.Columns[name]) {
+				if change.Attribute != "default" || len(change.New) > 0 {
+					m.create(id, columnStatements(tableName, name, change)...)
+				}
+				altered = altered || change.Attribute == "type"
+			}
+		}
+	}
+	for _, change := range changes[KindSequence] {
+		if change.Action == ActionAdded {
+			m.create(id, strings.Split(change.New, "\n")[1])
+		}
+	}
+	for _, change := range changes[KindConstraint] {
+		if change.Action == ActionRemoved {
+			continue
+		}
+		stmt := prefix + " ADD " + change.New + ";"
+		if to.Constraints[change.Object].Kind == ForeignKeyConstraint {
+			m.after = append(m.after, &migrationStatement{stmt, false})
+		} else {
+			m.create(id, stmt)
+		}
+	}
+	for _, kind := range []string{KindIndex, KindStatistics, KindRule} {
+		for _, change := range changes[kind] {
+			if change.Action != ActionRemoved {
+				m.create(id, change.New)
+			}
+		}
+	}
+	return altered
+}
+
+// kindOrder returns the ids of the graph ordered by their kinds in migration order
+func kindOrder(g *graph.Graph) []string {
+	var order []string
+	for _, kind := range migrationKinds {
+		for _, id := range g.Nodes() {
+			if objectKind(id) == kind {
+				order = append(order, id)
+			}
+		}
+	}
+	return order
+}
+
+// MigrateSchema returns the statements migrating one schema to another, grouped by object. Foreign keys are dropped
+// first and added last, objects are dropped in reverse dependency order of the old schema and created or altered in
+// dependency order of the new schema. Changed functions and procedures are replaced, changed sequences altered, labels
+// appended to enum types added and other changed objects dropped and created again, along with the views depending
+// on them or on tables with dropped or retyped columns. Changes which cannot be migrated by statement, such as other
+// changes of types and domains, are noted in comments.
+// Statements dropping tables, columns, sequences or objects used by kept columns lose data and are commented out or
+// refused as set by the options.
+func MigrateSchema(from, to *Schema, options *MigrateOptions) ([]string, error) {
+	fromTables, fromDeferred, _ := orderTables(from.Tables, TableOrderTopological, true)
+	toTables, toDeferred, _ := orderTables(to.Tables, TableOrderTopological, true)
+	fromGraph := dependencyGraph(from, fromTables, fromDeferred)
+	toGraph := dependencyGraph(to, toTables, toDeferred)
+	m := &migration{drops: make(map[string][]*migrationStatement), creates: make(map[string][]*migrationStatement)}
+	uses := columnUses(from, to)
+	var recreated []string
+
+	for _, kind := range migrationKinds {
+		if kind == KindTable || kind == KindFunction || kind == KindProcedure {
+			continue
+		}
+		fromDefs, toDefs := objectDefinitions(from, kind, options.ShowCredentials),
+			objectDefinitions(to, kind, options.ShowCredentials)
+		for _, change := range diffDefinitions(kind, "definition", fromDefs, toDefs) {
+			id := objectID(kind, change.Object)
+			switch {
+			case change.Action == ActionAdded:
+				m.create(id, change.New)
+			case change.Action == ActionRemoved:
+				m.drop(id, kind == KindSequence || uses[id], dropStatement(from, kind, change.Object))
+			case kind == KindSequence:
+				m.create(id, alterSequence(change.New))
+			case kind == KindType:
+				m.create(id, alterType(change.Object, from.Types[change.Object], to.Types[change.Object])...)
+			case kind == KindDomain:
+				m.create(id, byHand("domain %s changed from %q to %q", change.Object, change.Old, change.New))
+			default:
+				m.drop(id, uses[id], dropStatement(from, kind, change.Object))
+				m.create(id, change.New)
+				recreated = append(recreated, id)
+			}
+		}
+	}
+
+	for _, procedures := range []bool{false, true} {
+		kind := KindFunction
+		if procedures {
+			kind = KindProcedure
+		}
+		fromDefs := definitions(from.Functions, func(f *Function) string { return f.Statement })
+		toDefs := definitions(to.Functions, func(f *Function) string { return f.Statement })
+		retainKeys(fromDefs, func(signature string) bool { return from.Functions[signature].IsProcedure == procedures })
+		retainKeys(toDefs, func(signature string) bool { return to.Functions[signature].IsProcedure == procedures })
+		for _, change := range diffDefinitions(kind, "definition", fromDefs, toDefs) {
+			id := objectID(kind, change.Object)
+			switch change.Action {
+			case ActionAdded:
+				m.create(id, change.New)
+			case ActionRemoved:
+				m.drop(id, uses[id], dropStatement(from, kind, change.Object))
+			default:
+				m.create(id, orReplace(to.Functions[change.Object]))
+			}
+		}
+	}
+
+	for _, name := range fromTables {
+		if _, ok := to.Tables[name]; ok {
+			continue
+		}
+		m.drop(objectID(KindTable, name), true, "DROP TABLE "+name+";")
+		// foreign keys closing cycles between dropped tables must be dropped before either table
+		for _, constraintName := range sortedKeys(fromDeferred[name]) {
+			m.before = append(m.before, &migrationStatement{"ALTER TABLE " + name + " DROP CONSTRAINT " +
+				constraintName + ";", false})
+		}
+	}
+	for _, name := range toTables {
+		table := to.Tables[name]
+		if _, ok := from.Tables[name]; ok {
+			if migrateTable(m, name, from.Tables[name], table) {
+				recreated = append(recreated, objectID(KindTable, name))
+			}
+			continue
+		}
+		var b strings.Builder
+		printTableObjects(&b, name, table, nil, toDeferred[name], &PrintOptions{ColumnOrder: ColumnOrderPhysical})
+		m.create(objectID(KindTable, name), strings.TrimSuffix(b.String(), "\n"))
+		for _, index := range table.Index {
+			for _, stmt := range index.AttachStatements() {
+				m.after = append(m.after, &migrationStatement{stmt, false})
+			}
+		}
+		for _, constraintName := range sortedKeys(toDeferred[name]) {
+			m.after = append(m.after, &migrationStatement{"ALTER TABLE " + name + " ADD " +
+				table.Constraints[constraintName].Statement + ";", false})
+		}
+	}
+
+	// views depending on recreated objects are recreated with them unless they are changed themselves
+	toViews := objectDefinitions(to, KindView, options.ShowCredentials)
+	for _, id := range fromGraph.Dependents(-1, recreated...) {
+		name := strings.TrimPrefix(id, KindView+":")
+		if _, ok := m.drops[id]; objectKind(id) != KindView || ok || toViews[name] == "" {
+			continue
+		}
+		m.drop(id, false, dropStatement(from, KindView, name))
+		m.create(id, toViews[name])
+	}
+
+	var groups [][]*migrationStatement
+	if len(m.before) > 0 {
+		groups = append(groups, m.before)
+	}
+	order := fromGraph.SortInOrder(kindOrder(fromGraph))
+	for i := len(order) - 1; i >= 0; i-- {
+		if stmts, ok := m.drops[order[i]]; ok {
+			groups = append(groups, stmts)
+		}
+	}
+	for _, id := range toGraph.SortInOrder(kindOrder(toGraph)) {
+		if stmts, ok := m.creates[id]; ok {
+			groups = append(groups, stmts)
+		}
+	}
+	if len(m.after) > 0 {
+		groups = append(groups, m.after)
+	}
+
+	var statements []string
+	for _, group := range groups {
+		var stmts []string
+		for _, stmt := range group {
+			if !stmt.destructive || options.Destructive == DestructiveAllow {
+				stmts = append(stmts, stmt.stmt)
+			} else if options.Destructive == DestructiveRefuse {
+				return nil, fmt.Errorf("migrating schema - destructive statement %s", stmt.stmt)
+			} else {
+				stmts = append(stmts, "-- "+stmt.stmt)
+			}
+		}
+		statements = append(statements, strings.Join(stmts, "\n"))
+	}
+	return statements, nil
+}
@@ -0,0 +1,112 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMigrateSchema(t *testing.T) {
+	users := "CREATE TABLE public.users (\n    id integer NOT NULL\n);\n\n" +
+		"ALTER TABLE ONLY public.users\n    ADD CONSTRAINT users_pkey PRIMARY KEY (id);\n\n"
+	base := "CREATE FUNCTION public.count_orders() RETURNS bigint\n    LANGUAGE sql\n" +
+		"    AS $$ SELECT count(*) FROM orders $$;\n\n" +
+		"CREATE VIEW public.order_ids AS\n SELECT orders.id\n   FROM public.orders;\n\n" +
+		"CREATE TABLE public.orders (\n    id integer NOT NULL,\n    user_id integer DEFAULT 0,\n    note text\n);\n\n" +
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);\n\n" +
+		"CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id);\n"
+	changed := "CREATE FUNCTION public.count_orders() RETURNS bigint\n    LANGUAGE sql\n" +
+		"    AS $$ SELECT count(*) FROM orders WHERE id > 0 $$;\n\n" +
+		"CREATE VIEW public.order_ids AS\n SELECT orders.id\n   FROM public.orders;\n\n" + users +
+		"CREATE TABLE public.orders (\n    id bigint NOT NULL,\n    user_id integer NOT NULL\n);\n\n" +
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);\n\n" +
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) " +
+		"REFERENCES public.users(id);\n\n" +
+		"CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id, id);\n"
+	types := "CREATE TYPE public.mood AS ENUM (\n    'sad',\n    'ok'\n);\n\n" +
+		"CREATE DOMAIN public.score AS integer\n\tCONSTRAINT score_check CHECK ((VALUE >= 0));\n\n"
+	changedTypes := "CREATE TYPE public.mood AS ENUM (\n    'sad',\n    'ok',\n    'happy'\n);\n\n" +
+		"CREATE DOMAIN public.score AS integer\n\tCONSTRAINT score_check CHECK ((VALUE > 0));\n\n"
+	moods := "CREATE TABLE public.moods (\n    id integer NOT NULL,\n    mood public.mood,\n    score public.score\n);\n"
+	untypedMoods := "CREATE TABLE public.moods (\n    id integer NOT NULL,\n    mood text,\n    score integer\n);\n"
+
+	tests := []struct {
+		name               string
+		from               string
+		to                 string
+		destructive        string
+		expectedStatements []string
+		hasError           bool
+	}{
+		{name: "Same schema", from: base, to: base, destructive: DestructiveRefuse},
+		{
+			name:        "Changed schema",
+			from:        base,
+			to:          changed,
+			destructive: DestructiveComment,
+			expectedStatements: []string{
+				"DROP VIEW order_ids;",
+				"DROP INDEX orders_user_id_idx;\n-- ALTER TABLE orders DROP COLUMN note;\n" +
+					"ALTER TABLE orders ALTER COLUMN user_id DROP DEFAULT;",
+				"CREATE TABLE users (\n    id integer NOT NULL,\n    CONSTRAINT users_pkey PRIMARY KEY (id)\n);",
+				"ALTER TABLE orders ALTER COLUMN id TYPE bigint;\n" +
+					"ALTER TABLE orders ALTER COLUMN user_id SET NOT NULL;\n" +
+					"CREATE INDEX orders_user_id_idx ON orders USING btree (user_id, id);",
+				"CREATE OR REPLACE FUNCTION count_orders() RETURNS bigint\n    LANGUAGE sql\n" +
+					"    AS $$ SELECT count(*) FROM orders WHERE id > 0 $$;",
+				"CREATE VIEW order_ids AS\n SELECT orders.id\n   FROM orders;",
+				"ALTER TABLE orders ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);",
+			},
+		},
+		{
+			name:        "Dropped table",
+			from:        users + base,
+			to:          base,
+			destructive: DestructiveAllow,
+			expectedStatements: []string{
+				"DROP TABLE users;",
+			},
+		},
+		{
+			name:        "Changed types used by columns",
+			from:        types + moods,
+			to:          changedTypes + moods,
+			destructive: DestructiveRefuse,
+			expectedStatements: []string{
+				"ALTER TYPE mood ADD VALUE 'happy';",
+				"-- domain score changed from \"CREATE DOMAIN score AS integer CONSTRAINT score_check CHECK ((VALUE >= 0));\" " +
+					"to \"CREATE DOMAIN score AS integer CONSTRAINT score_check CHECK ((VALUE > 0));\" and must be migrated by hand",
+			},
+		},
+		{
+			name:        "Dropped types used by columns",
+			from:        types + moods,
+			to:          untypedMoods,
+			destructive: DestructiveComment,
+			expectedStatements: []string{
+				"-- DROP DOMAIN score;",
+				"-- DROP TYPE mood;",
+				"ALTER TABLE moods ALTER COLUMN mood TYPE text;\nALTER TABLE moods ALTER COLUMN score TYPE integer;",
+			},
+		},
+		{name: "Refused destructive statement", from: users + base, to: base, destructive: DestructiveRefuse,
+			hasError: true},
+	}
+	for _, test := range tests {
+		statements, err := MigrateSchema(readTestSchema(t, test.from), readTestSchema(t, test.to),
+			&MigrateOptions{Destructive: test.destructive})
+		if test.hasError {
+			if err == nil {
+				t.Error(test.name + " - error expected")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(test.name + " - unexpected error")
+			continue
+		}
+		if !cmp.Equal(statements, test.expectedStatements) {
+			t.Error(test.name + " - statements error")
+		}
+	}
+}
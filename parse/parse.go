@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"regexp"
 	"sort"
 	"strings"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/jchiam/psql-schema-dump-sanitiser/graph"
 )
 
@@ -18,6 +20,11 @@ type Column struct {
 	Statement    string
 	IsPrimaryKey bool
 	IsForeignKey bool
+	IsUnique     bool
+	Position     int
+	Statistics   string
+	Storage      string
+	Options      []string
 }
 
 // Sequence holds the create and relation statements of a table
@@ -26,12 +33,35 @@ type Sequence struct {
 	Relation string
 }
 
+// IndependentSequence holds the create statement of a sequence not owned by a table column
+type IndependentSequence struct {
+	Name      string
+	Schema    string
+	Statement string
+}
+
+// Trigger holds the create statement of a trigger and the table it is defined on
+type Trigger struct {
+	Name      string
+	Table     string
+	Schema    string
+	Statement string
+}
+
 // Table is the struct containing logical aspects of a psql table's structure
 type Table struct {
-	Columns     map[string]*Column
-	Constraints map[string]string
-	Sequences   []*Sequence
-	Index       []string
+	Schema            string
+	Columns           map[string]*Column
+	Constraints       map[string]*Constraint
+	Sequences         []*Sequence
+	Index             []*Index
+	Rules             []string
+	Statistics        []*Statistics
+	StorageParameters []string
+	Clauses           []string
+	ClusterOn         string
+	ReplicaIdentity   string
+	Position          int
 }
 
 func similarColumns(cols1, cols2 map[string]*Column) bool {
@@ -40,8 +70,9 @@ func similarColumns(cols1, cols2 map[string]*Column) bool {
 	} else if len(cols1) == 0 {
 		return true
 	}
+	// the position of a column depends on the order of the dump rather than its definition
 	for col := range cols1 {
-		if !cmp.Equal(cols1[col], cols2[col]) {
+		if !cmp.Equal(cols1[col], cols2[col], cmpopts.IgnoreFields(Column{}, "Position")) {
 			return false
 		}
 	}
@@ -62,7 +93,7 @@ func similarSequences(seqs1, seqs2 []*Sequence) bool {
 	return true
 }
 
-func similarConstraints(cons1, cons2 map[string]string) bool {
+func similarConstraints(cons1, cons2 map[string]*Constraint) bool {
 	if len(cons1) != len(cons2) {
 		return false
 	} else if len(cons1) == 0 {
@@ -76,11 +107,56 @@ func similarConstraints(cons1, cons2 map[string]string) bool {
 	return true
 }
 
-// IsDeepEqual compares the two tables and returns whether they are deeply equal
+// Schema holds all objects parsed from a schema dump
+type Schema struct {
+	Tables        map[string]*Table
+	Sequences     []*IndependentSequence
+	Functions     map[string]*Function
+	Triggers      []*Trigger
+	Types         map[string]*Type
+	Domains       map[string]*Domain
+	Aggregates    map[string]*Aggregate
+	Operators     map[string]*Operator
+	Casts         map[string]*Cast
+	Collations    map[string]*Collation
+	Dictionaries  map[string]*TextSearchDictionary
+	Configs       map[string]*TextSearchConfiguration
+	Views         map[string]*View
+	EventTriggers map[string]*EventTrigger
+	Wrappers      map[string]*ForeignDataWrapper
+	Servers       map[string]*ForeignServer
+	UserMappings  map[string]*UserMapping
+	ForeignTables map[string]*ForeignTable
+	Publications  map[string]*Publication
+	Subscriptions map[string]*Subscription
+}
+
+// PrintOptions holds the options controlling how the schema is printed
+type PrintOptions struct {
+	ShowCredentials bool
+	TypeStyle       string
+	CollapseSerials bool
+	ColumnOrder     string
+	TableOrder      string
+	BreakCycles     bool
+}
+
+// Column orders columns can be printed in
+const (
+	ColumnOrderGrouped      = "grouped"
+	ColumnOrderPhysical     = "physical"
+	ColumnOrderAlphabetical = "alphabetical"
+)
+
+// IsDeepEqual compares the two tables and returns whether they are deeply equal, ignoring the positions of the table
+// and its columns in the dump
 func (t Table) IsDeepEqual(table *Table) bool {
 	if !similarColumns(t.Columns, table.Columns) || !similarSequences(t.Sequences, table.Sequences) ||
 		!similarConstraints(t.Constraints, table.Constraints) || !cmp.Equal(t.Sequences, table.Sequences) ||
-		!cmp.Equal(t.Index, table.Index) {
+		!cmp.Equal(t.Index, table.Index) || !cmp.Equal(t.Rules, table.Rules) ||
+		!cmp.Equal(t.Statistics, table.Statistics) || !cmp.Equal(t.StorageParameters, table.StorageParameters) ||
+		!cmp.Equal(t.Clauses, table.Clauses) || t.ClusterOn != table.ClusterOn ||
+		t.ReplicaIdentity != table.ReplicaIdentity || t.Schema != table.Schema {
 		return false
 	}
 	return true
@@ -119,25 +195,39 @@ func removeAccessModifier(s string) (string, string) {
 
 // MapTables parses sql statements and returns a map of Table structs containing information of table's structure
 // and the remaining unprocessed lines
-func MapTables(lines []string) (map[string]*Table, []string) {
+func MapTables(lines []string) (map[string]*Table, []string, error) {
 	tables := make(map[string]*Table)
 	if len(lines) == 0 {
-		return tables, lines
+		return tables, lines, nil
 	}
 
 	var bufferLines []string
 	for i := 0; i < len(lines); i++ {
 		line := lines[i]
-		if strings.Contains(line, "CREATE TABLE") {
-			tableName, _ := removeAccessModifier(strings.Split(line, " ")[2])
+		if strings.HasPrefix(line, "CREATE TABLE ") {
+			tableName, modifier := removeAccessModifier(strings.Split(line, " ")[2])
 			table := Table{
+				Schema:      modifier,
 				Columns:     make(map[string]*Column),
-				Constraints: make(map[string]string),
+				Constraints: make(map[string]*Constraint),
+				Position:    len(tables) + 1,
 			}
 
 			j := i + 1
-			for ; lines[j][len(lines[j])-1] != ';'; j++ {
+			for ; j < len(lines) && !strings.HasPrefix(strings.Trim(lines[j], " "), ")"); j++ {
 				columnLine := strings.Trim(lines[j], " ")
+				if strings.HasPrefix(columnLine, "CONSTRAINT ") {
+					def := strings.TrimSuffix(columnLine, ",")
+					if len(modifier) > 0 {
+						def = strings.Replace(def, modifier+".", "", -1)
+					}
+					constraint, err := parseConstraint(def)
+					if err != nil {
+						return tables, lines, fmt.Errorf("mapping tables - %s", err)
+					}
+					table.Constraints[constraint.Name] = constraint
+					continue
+				}
 				spaceIndex := strings.Index(columnLine, " ")
 				columnName := columnLine[:spaceIndex]
 				if columnLine[len(columnLine)-1] == ',' {
@@ -145,16 +235,29 @@ func MapTables(lines []string) (map[string]*Table, []string) {
 						Statement:    columnLine[spaceIndex+1 : len(columnLine)-1],
 						IsPrimaryKey: false,
 						IsForeignKey: false,
+						Position:     len(table.Columns) + 1,
 					}
 				} else {
 					table.Columns[columnName] = &Column{
 						Statement:    columnLine[spaceIndex+1:],
 						IsPrimaryKey: false,
 						IsForeignKey: false,
+						Position:     len(table.Columns) + 1,
 					}
 				}
 			}
 
+			// the closing parenthesis may be followed by storage parameters and other clauses over several lines
+			suffix := ""
+			for ; j < len(lines); j++ {
+				suffix += " " + strings.Trim(lines[j], " ")
+				if statementEnd(suffix) != -1 {
+					break
+				}
+			}
+			suffix = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(suffix), ")"), ";")
+			table.StorageParameters, table.Clauses = parseTableClauses(suffix)
+
 			tables[tableName] = &table
 			i = j
 		} else {
@@ -162,34 +265,19 @@ func MapTables(lines []string) (map[string]*Table, []string) {
 		}
 	}
 
-	return tables, bufferLines
+	return tables, bufferLines, nil
 }
 
+// defaultSequenceOptionExp matches create sequence options set to their default values
+var defaultSequenceOptionExp = regexp.MustCompile(`\b(START WITH 1|INCREMENT BY 1|NO MINVALUE|NO MAXVALUE|CACHE 1)\b`)
+
 func simplifyCreateSequenceStatement(stmt string) string {
 	if len(stmt) == 0 || !strings.Contains(stmt, "CREATE SEQUENCE") {
 		return stmt
 	}
 
-	index := strings.Index(stmt, "START WITH 1")
-	if index != -1 {
-		stmt = stmt[:index] + stmt[index+len("START WITH 1"):]
-	}
-	index = strings.Index(stmt, "INCREMENT BY 1")
-	if index != -1 {
-		stmt = stmt[:index] + stmt[index+len("INCREMENT BY 1"):]
-	}
-	index = strings.Index(stmt, "NO MINVALUE")
-	if index != -1 {
-		stmt = stmt[:index] + stmt[index+len("NO MINVALUE"):]
-	}
-	index = strings.Index(stmt, "NO MAXVALUE")
-	if index != -1 {
-		stmt = stmt[:index] + stmt[index+len("NO MAXVALUE"):]
-	}
-	index = strings.Index(stmt, "CACHE 1")
-	if index != -1 {
-		stmt = stmt[:index] + stmt[index+len("CACHE 1"):]
-	}
+	// remove options with default values, matching whole values only so "START WITH 10" is kept
+	stmt = defaultSequenceOptionExp.ReplaceAllString(stmt, "")
 
 	multipleWhiteSpaceExp := regexp.MustCompile(`[\s]{2,}`)
 	stmt = multipleWhiteSpaceExp.ReplaceAllString(stmt, " ")
@@ -256,22 +344,31 @@ func MapSequences(lines []string, tables map[string]*Table) ([]string, error) {
 	return bufferLines, nil
 }
 
+// sequenceName returns the name and schema of the sequence created by the statement
+func sequenceName(stmt string) (string, string) {
+	tokens := strings.Fields(strings.TrimSuffix(stmt, ";"))
+	if len(tokens) < 3 {
+		return "", ""
+	}
+	return removeAccessModifier(tokens[2])
+}
+
 // StoreSequences parses sql statements and squashes them into a single create sequence statement.
 // It then returns the remaining lines and sequences.
 // Note: Assumes sequences with related tables have been processed and removed.
-func StoreSequences(lines []string) ([]string, []string, error) {
+func StoreSequences(lines []string) ([]string, []*IndependentSequence, error) {
 	if len(lines) == 0 {
 		return lines, nil, nil
 	}
 
-	var bufferLines, seqs []string
+	var bufferLines []string
+	var seqs []*IndependentSequence
 	for _, line := range lines {
 		if strings.Contains(line, "CREATE SEQUENCE") {
-			seq := simplifyCreateSequenceStatement(line)
-
-			_, modifier := removeAccessModifier(strings.Split(line, " ")[2])
-			if len(modifier) > 0 {
-				seq = strings.Replace(seq, modifier+".", "", -1)
+			seq := &IndependentSequence{Statement: simplifyCreateSequenceStatement(line)}
+			seq.Name, seq.Schema = sequenceName(line)
+			if len(seq.Schema) > 0 {
+				seq.Statement = strings.Replace(seq.Statement, seq.Schema+".", "", -1)
 			}
 			seqs = append(seqs, seq)
 		} else {
@@ -295,7 +392,7 @@ func MapDefaultValues(lines []string, tables map[string]*Table) ([]string, error
 	var modifier string
 	for _, line := range lines {
 		index := strings.Index(line, "DEFAULT")
-		if index != -1 {
+		if strings.HasPrefix(line, "ALTER TABLE ") && strings.Contains(line, " SET DEFAULT ") {
 			tokens := strings.Split(line, " ")
 			var tableName, columnName string
 			if tokens[2] == "ONLY" {
@@ -343,47 +440,51 @@ func MapConstraints(lines []string, tables map[string]*Table) ([]string, error)
 
 	var bufferLines []string
 	for _, line := range lines {
-		// check for "CONSTRAINT" and not a trigger function
-		index := strings.Index(line, "CONSTRAINT")
-		if index != -1 && !strings.Contains(line, "CONSTRAINT TRIGGER") {
-			tokens := strings.Split(line, " ")
-			tableName, modifier := removeAccessModifier(tokens[3])
-			constraintName := tokens[6]
-			if table, ok := tables[tableName]; ok {
-				if len(modifier) > 0 {
-					table.Constraints[constraintName] = strings.Replace(line[index:len(line)-1], modifier+".", "", -1)
-				} else {
-					table.Constraints[constraintName] = line[index : len(line)-1]
-				}
-			} else {
-				return lines, fmt.Errorf("mapping constraints - table does not exist")
-			}
+		index := strings.Index(line, " ADD CONSTRAINT ")
+		if !strings.HasPrefix(line, "ALTER TABLE ") || index == -1 {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
 
-			// update column primary or foreign keys
-			if strings.Contains(line, "PRIMARY KEY") {
-				columns := strings.Split(line[strings.Index(line, "(")+1:strings.Index(line, ")")], ", ")
-				for _, column := range columns {
-					if currentCol, ok := tables[tableName].Columns[column]; ok {
-						currentCol.IsPrimaryKey = true
-					} else {
-						delete(tables[tableName].Constraints, constraintName)
-						return lines, fmt.Errorf("mapping constraints - column does not exist")
-					}
-				}
-			} else if strings.Contains(line, "FOREIGN KEY") {
-				columns := strings.Split(line[strings.Index(line, "(")+1:strings.Index(line, ")")], ", ")
-				for _, column := range columns {
-					if currentCol, ok := tables[tableName].Columns[column]; ok {
-						currentCol.IsForeignKey = true
-					} else {
-						delete(tables[tableName].Constraints, constraintName)
-						return lines, fmt.Errorf("column does not exist")
-					}
+		tokens := strings.Split(line, " ")
+		tableName, modifier := removeAccessModifier(tokens[2])
+		if tokens[2] == "ONLY" {
+			tableName, modifier = removeAccessModifier(tokens[3])
+		}
+		table, ok := tables[tableName]
+		if !ok {
+			return lines, fmt.Errorf("mapping constraints - table does not exist")
+		}
+
+		def := strings.TrimSuffix(line[index+len(" ADD "):], ";")
+		if len(modifier) > 0 {
+			def = strings.Replace(def, modifier+".", "", -1)
+		}
+		constraint, err := parseConstraint(def)
+		if err != nil {
+			return lines, fmt.Errorf("mapping constraints - %s", err)
+		}
+
+		// update column primary keys, foreign keys and unique columns
+		for _, column := range constraint.Columns {
+			if _, ok := table.Columns[column]; !ok {
+				return lines, fmt.Errorf("mapping constraints - column does not exist")
+			}
+		}
+		for _, column := range constraint.Columns {
+			switch constraint.Kind {
+			case PrimaryKeyConstraint:
+				table.Columns[column].IsPrimaryKey = true
+			case ForeignKeyConstraint:
+				table.Columns[column].IsForeignKey = true
+			case UniqueConstraint:
+				// only a single column unique constraint makes the column itself unique
+				if len(constraint.Columns) == 1 {
+					table.Columns[column].IsUnique = true
 				}
 			}
-		} else {
-			bufferLines = append(bufferLines, line)
 		}
+		table.Constraints[constraint.Name] = constraint
 	}
 
 	return bufferLines, nil
@@ -399,25 +500,27 @@ func MapIndices(lines []string, tables map[string]*Table) ([]string, error) {
 	}
 
 	var bufferLines []string
-	var modifier string
 	for _, line := range lines {
-		if strings.Contains(line, "INDEX") {
-			tokens := strings.Split(line, " ")
-			tableName := ""
-			for i := range tokens {
-				if tokens[i] == "ON" {
-					tableName, modifier = removeAccessModifier(tokens[i+1])
-				}
+		if strings.HasPrefix(line, "CREATE INDEX ") || strings.HasPrefix(line, "CREATE UNIQUE INDEX ") {
+			index, err := parseIndex(line)
+			if err != nil {
+				return lines, fmt.Errorf("mapping indices - %s", err)
 			}
-			if table, ok := tables[tableName]; ok {
-				if len(modifier) > 0 {
-					table.Index = append(table.Index, strings.Replace(line, modifier+".", "", -1))
-				} else {
-					table.Index = append(table.Index, line)
-				}
+			if table, ok := tables[index.Table]; ok {
+				table.Index = append(table.Index, index)
 			} else {
 				return lines, fmt.Errorf("mapping indices - table does not exist")
 			}
+		} else if strings.HasPrefix(line, "ALTER INDEX ") && strings.Contains(line, " ATTACH PARTITION ") {
+			// indexes of partitions are attached to the index of their partitioned table
+			tokens := strings.Split(strings.TrimSuffix(line, ";"), " ")
+			parentName, _ := removeAccessModifier(tokens[2])
+			partitionName, _ := removeAccessModifier(tokens[len(tokens)-1])
+			if parent := findIndex(tables, parentName); parent != nil {
+				parent.Partitions = append(parent.Partitions, partitionName)
+			} else {
+				return lines, fmt.Errorf("mapping indices - index does not exist")
+			}
 		} else {
 			bufferLines = append(bufferLines, line)
 		}
@@ -454,12 +557,20 @@ func SquashMultiLineStatements(lines []string) []string {
 	return bufferLines
 }
 
-func printColumns(table *Table) {
+func columnStatement(columnName string, column *Column, serials map[string]string, typeStyle string) string {
+	if serial, ok := serials[columnName]; ok {
+		return serialStatement(column, serial)
+	}
+	return column.NormalisedStatement(typeStyle)
+}
+
+// orderColumns returns the names of the columns of the table in the given column order
+func orderColumns(table *Table, order string) []string {
 	var primaryKeyColumns, foreignKeyColumns, columns []string
 	for k, v := range table.Columns {
-		if v.IsPrimaryKey {
+		if order == ColumnOrderGrouped && v.IsPrimaryKey {
 			primaryKeyColumns = append(primaryKeyColumns, k)
-		} else if v.IsForeignKey {
+		} else if order == ColumnOrderGrouped && v.IsForeignKey {
 			foreignKeyColumns = append(foreignKeyColumns, k)
 		} else {
 			columns = append(columns, k)
@@ -468,234 +579,332 @@ func printColumns(table *Table) {
 	sort.Strings(primaryKeyColumns)
 	sort.Strings(foreignKeyColumns)
 	sort.Strings(columns)
-
-	for i, columnName := range primaryKeyColumns {
-		column := table.Columns[columnName]
-		fmt.Printf("    %s %s", columnName, column.Statement)
-		if i == len(primaryKeyColumns)-1 && len(foreignKeyColumns) == 0 && len(columns) == 0 && len(table.Constraints) == 0 {
-			fmt.Println()
-		} else {
-			fmt.Print(",\n")
-		}
+	if order == ColumnOrderPhysical {
+		sort.SliceStable(columns, func(i, j int) bool {
+			return table.Columns[columns[i]].Position < table.Columns[columns[j]].Position
+		})
 	}
 
-	for i, columnName := range foreignKeyColumns {
-		column := table.Columns[columnName]
-		fmt.Printf("    %s %s", columnName, column.Statement)
-		if i == len(foreignKeyColumns)-1 && len(columns) == 0 && len(table.Constraints) == 0 {
-			fmt.Println()
-		} else {
-			fmt.Print(",\n")
-		}
-	}
+	return append(append(primaryKeyColumns, foreignKeyColumns...), columns...)
+}
 
-	for i, columnName := range columns {
+func printColumns(w io.Writer, table *Table, serials map[string]string, deferred map[string]bool,
+	options *PrintOptions) {
+	constraintCount := len(constraintNames(table, true, deferred))
+	columnNames := orderColumns(table, options.ColumnOrder)
+	for i, columnName := range columnNames {
 		column := table.Columns[columnName]
-		fmt.Printf("    %s %s", columnName, column.Statement)
-		if i == len(columns)-1 && len(table.Constraints) == 0 {
-			fmt.Println()
+		fmt.Fprintf(w, "    %s %s", columnName, columnStatement(columnName, column, serials, options.TypeStyle))
+		if i == len(columnNames)-1 && constraintCount == 0 {
+			fmt.Fprintln(w)
 		} else {
-			fmt.Print(",\n")
+			fmt.Fprint(w, ",\n")
 		}
 	}
 }
 
-func printConstraints(table *Table) {
-	i := 0
-	constraintNames := make([]string, len(table.Constraints))
-	for name := range table.Constraints {
-		constraintNames[i] = name
-		i++
-	}
-	sort.Strings(constraintNames)
-
-	for i, constraint := range constraintNames {
-		fmt.Printf("    %s", table.Constraints[constraint])
-		if i == len(table.Constraints)-1 {
-			fmt.Println()
-		} else {
-			fmt.Print(",\n")
+// constraintNames returns the sorted names of the constraints of the table which are valid or not, leaving out the
+// deferred constraints
+func constraintNames(table *Table, valid bool, deferred map[string]bool) []string {
+	var names []string
+	for name, constraint := range table.Constraints {
+		if constraint.NotValid != valid && !deferred[name] {
+			names = append(names, name)
 		}
 	}
+	sort.Strings(names)
+	return names
 }
 
-func printTable(tableName string, table *Table) {
-	fmt.Printf("CREATE TABLE %s (\n", tableName)
-	printColumns(table)
-	printConstraints(table)
-	fmt.Println(");")
-}
-
-func getReferenceTables(tableName string, tables map[string]*Table) []string {
-	var refTables []string
-	for _, constraint := range tables[tableName].Constraints {
-		if strings.Contains(constraint, "FOREIGN KEY") {
-			tokens := strings.Split(constraint, "REFERENCES ")
-			ref, _ := removeAccessModifier(tokens[1][:strings.Index(tokens[1], "(")])
-			refTables = append(refTables, ref)
+func printConstraints(w io.Writer, table *Table, deferred map[string]bool) {
+	names := constraintNames(table, true, deferred)
+	for i, constraint := range names {
+		fmt.Fprintf(w, "    %s", table.Constraints[constraint].Statement)
+		if i == len(names)-1 {
+			fmt.Fprintln(w)
+		} else {
+			fmt.Fprint(w, ",\n")
 		}
 	}
-	return refTables
 }
 
-// Sort tables topologically
-func sortTables(tables map[string]*Table) []string {
-	nodes := make(map[string]*graph.Node)
-
-	// create nodes
-	for name := range tables {
-		if name == "gorp_migrations" {
-			continue
-		}
-		nodes[name] = &graph.Node{
-			ID:       name,
-			Parents:  make(map[string]*graph.Node),
-			Children: make(map[string]*graph.Node),
+func printTable(w io.Writer, tableName string, table *Table, serials map[string]string, deferred map[string]bool,
+	options *PrintOptions) {
+	fmt.Fprintf(w, "CREATE TABLE %s (\n", tableName)
+	printColumns(w, table, serials, deferred, options)
+	printConstraints(w, table, deferred)
+	fmt.Fprint(w, ")")
+	// storage parameters must precede the tablespace clause
+	var tablespaces []string
+	for _, clause := range table.Clauses {
+		if strings.HasPrefix(clause, "TABLESPACE ") {
+			tablespaces = append(tablespaces, clause)
+		} else {
+			fmt.Fprintf(w, "\n%s", clause)
 		}
 	}
-
-	// add edges
-	for id, node := range nodes {
-		parents := getReferenceTables(id, tables)
-		for _, parent := range parents {
-			// exclude self-referencing tables
-			if parent != id {
-				node.Parents[parent] = nodes[parent]
-				nodes[parent].Children[id] = node
-			}
-		}
+	if len(table.StorageParameters) > 0 {
+		fmt.Fprintf(w, "\nWITH (%s)", strings.Join(table.StorageParameters, ", "))
 	}
+	for _, clause := range tablespaces {
+		fmt.Fprintf(w, "\n%s", clause)
+	}
+	fmt.Fprintln(w, ";")
+}
 
-	i := 0
-	sortedNodeIDs := make([]string, len(nodes))
-	for len(nodes) > 0 {
-		var rootNodeIDs []string
-		for id, node := range nodes {
-			if len(node.Parents) == 0 {
-				rootNodeIDs = append(rootNodeIDs, id)
-			}
-		}
-
-		if len(rootNodeIDs) == 0 {
-			log.Fatal(fmt.Errorf("graph is not a dag"))
-		}
-
-		sort.Strings(rootNodeIDs)
-		for _, id := range rootNodeIDs {
-			for _, childNode := range nodes[id].Children {
-				delete(childNode.Parents, id)
-			}
-			delete(nodes, id)
-			sortedNodeIDs[i] = id
-			i++
-		}
+// Sort tables topologically. If the foreign keys form a cycle, an error with the cycle is returned unless breakCycles
+// is set, in which case the reference closing the cycle is ignored and the foreign keys it consists of are deferred.
+func sortTables(tables map[string]*Table, breakCycles bool) ([]string, error) {
+	g := tableGraph(tables)
+	if breakCycles {
+		sorted, _ := g.SortBreakingCycles()
+		return sorted, nil
 	}
-	return sortedNodeIDs
+	sorted, err := g.Sort()
+	if cycle, ok := err.(*graph.CycleError); ok {
+		return nil, fmt.Errorf("sorting tables - foreign key cycle %s", strings.Join(cycle.Path, " -> "))
+	}
+	return sorted, err
 }
 
-// StoreFunctions parses sql statements for functions.
-// It then returns the remaining lines and sequences.
-func StoreFunctions(lines []string) ([]string, []string, error) {
+// StoreTriggers parses sql statements for triggers and trigger functions.
+// It then returns the remaining lines and triggers.
+func StoreTriggers(lines []string) ([]string, []*Trigger, error) {
 	if len(lines) == 0 {
 		return lines, nil, nil
 	}
 
-	var bufferLines, functions []string
-	var isProcessingFunction bool
-	var accessModifier string
+	var bufferLines []string
+	var triggers []*Trigger
 	for _, line := range lines {
-		if strings.Contains(line, "CREATE FUNCTION") {
-			isProcessingFunction = true
-
-			_, accessModifier = removeAccessModifier(strings.Split(line, " ")[2])
-			if len(accessModifier) > 0 {
-				line = strings.Replace(line, accessModifier+".", "", -1)
+		if strings.HasPrefix(line, "CREATE TRIGGER ") || strings.HasPrefix(line, "CREATE CONSTRAINT TRIGGER ") {
+			tokens := strings.Split(line, " ")
+			trigger := &Trigger{Name: tokens[2]}
+			if tokens[1] == "CONSTRAINT" {
+				trigger.Name = tokens[3]
 			}
-
-			functions = append(functions, line)
-		} else if isProcessingFunction {
-			functions = append(functions, line)
-
-			if line == "$$;" {
-				isProcessingFunction = false
+			for i := range tokens {
+				if tokens[i] == "ON" && i+1 < len(tokens) {
+					trigger.Table, trigger.Schema = removeAccessModifier(tokens[i+1])
+					break
+				}
 			}
+			if len(trigger.Table) == 0 {
+				return lines, triggers, fmt.Errorf("storing triggers - missing trigger table")
+			}
+			trigger.Statement = line
+			if len(trigger.Schema) > 0 {
+				trigger.Statement = strings.Replace(line, trigger.Schema+".", "", -1)
+			}
+
+			triggers = append(triggers, trigger)
 		} else {
 			bufferLines = append(bufferLines, line)
 		}
 	}
 
-	return bufferLines, functions, nil
+	return bufferLines, triggers, nil
 }
 
-// StoreTriggers parses sql statements for triggers and trigger functions.
-// It then returns the remaining lines and sequences.
-func StoreTriggers(lines []string) ([]string, []string, error) {
-	if len(lines) == 0 {
-		return lines, nil, nil
-	}
+// printSections maps the kinds of printed objects to the sections they are printed in. Sections are separated by
+// blank lines.
+var printSections = map[string]string{
+	KindDictionary:    "text search",
+	KindConfiguration: "text search",
+	KindAggregate:     "catalog",
+	KindOperator:      "catalog",
+	KindCast:          "catalog",
+	KindWrapper:       "foreign server",
+	KindServer:        "foreign server",
+	KindUserMapping:   "foreign server",
+	KindPublication:   "replication",
+	KindSubscription:  "replication",
+}
 
-	var bufferLines, triggers []string
-	var accessModifier string
-	for _, line := range lines {
-		if strings.Contains(line, "CREATE TRIGGER") || strings.Contains(line, "CREATE CONSTRAINT TRIGGER") {
-			_, accessModifier = removeAccessModifier(strings.Split(line, " ")[2])
-			if len(accessModifier) > 0 {
-				line = strings.Replace(line, accessModifier+".", "", -1)
-			}
+// blockKinds are the kinds of objects separated from each other by blank lines
+var blockKinds = map[string]bool{
+	KindTable:        true,
+	KindForeignTable: true,
+	KindView:         true,
+}
 
-			triggers = append(triggers, line)
-		} else {
-			bufferLines = append(bufferLines, line)
+// printTableObjects prints the table along with its sequences, indexes, attributes, statistics and rules
+func printTableObjects(w io.Writer, tableName string, table *Table, publications []string, deferred map[string]bool,
+	options *PrintOptions) {
+	if len(publications) > 0 {
+		fmt.Fprintf(w, "-- Publications: %s\n", strings.Join(publications, ", "))
+	}
+	sequences := table.Sequences
+	var serials map[string]string
+	if options.CollapseSerials {
+		serials, sequences = collapseSerials(tableName, table)
+	}
+	for _, seq := range sequences {
+		fmt.Fprintln(w, seq.Create)
+	}
+	printTable(w, tableName, table, serials, deferred, options)
+	for _, seq := range sequences {
+		fmt.Fprintln(w, seq.Relation)
+	}
+	// constraints not validated against existing rows cannot be declared in the create table statement
+	for _, name := range constraintNames(table, false, deferred) {
+		fmt.Fprintf(w, "ALTER TABLE %s ADD %s;\n", tableName, table.Constraints[name].Statement)
+	}
+	for _, index := range table.Index {
+		fmt.Fprintln(w, index.Statement())
+	}
+	for _, stmt := range table.AttributeStatements(tableName) {
+		fmt.Fprintln(w, stmt)
+	}
+	for _, statistics := range table.Statistics {
+		for _, stmt := range statistics.Statements(tableName) {
+			fmt.Fprintln(w, stmt)
 		}
 	}
-
-	return bufferLines, triggers, nil
+	for _, rule := range table.Rules {
+		fmt.Fprintln(w, rule)
+	}
 }
 
-// PrintSchema prints the schema into palatable form in console output
-func PrintSchema(tables map[string]*Table, seqs, functions, triggers []string) {
-	// print independent sequences
-	for _, seq := range seqs {
-		fmt.Println(seq)
+// PrintSchema prints the schema into palatable form in console output.
+// Objects are printed in sections by kind, starting with collations, text search objects, types, domains and
+// sequences, followed by the tables in table order, functions, procedures and the objects built from them, foreign
+// tables, views, publications and triggers. Objects are moved ahead of their sections where needed so every object is
+// printed after the objects it depends on.
+func PrintSchema(schema *Schema, options *PrintOptions) error {
+	// order tables before printing anything so a foreign key cycle does not leave partial output
+	tables := schema.Tables
+	tableNames, deferred, err := orderTables(tables, options.TableOrder, options.BreakCycles)
+	if err != nil {
+		return err
 	}
-	fmt.Println()
 
-	// print tables
-	tableNames := sortTables(tables)
-	for i, tableName := range tableNames {
-		table := tables[tableName]
-		if len(table.Sequences) > 0 {
-			for _, seq := range table.Sequences {
-				fmt.Println(seq.Create)
-			}
-			printTable(tableName, table)
-			for _, seq := range table.Sequences {
-				fmt.Println(seq.Relation)
-			}
-		} else {
-			printTable(tableName, table)
-		}
-		if len(table.Index) > 0 {
-			for _, index := range table.Index {
-				fmt.Println(index)
-			}
+	var order []string
+	printers := make(map[string]func())
+	add := func(id string, print func()) {
+		order = append(order, id)
+		printers[id] = print
+	}
+	statement := func(stmt string) func() {
+		return func() { fmt.Println(stmt) }
+	}
+
+	for _, name := range sortedKeys(schema.Collations) {
+		add(objectID(KindCollation, name), statement(schema.Collations[name].Statement))
+	}
+	// text search objects precede the tables and indexes referencing them
+	for _, name := range sortedKeys(schema.Dictionaries) {
+		add(objectID(KindDictionary, name), statement(schema.Dictionaries[name].Statement))
+	}
+	for _, name := range sortedKeys(schema.Configs) {
+		add(objectID(KindConfiguration, name), statement(strings.Join(schema.Configs[name].Statements(), "\n")))
+	}
+	for _, name := range sortedKeys(schema.Types) {
+		add(objectID(KindType, name), statement(schema.Types[name].Statement))
+	}
+	for _, name := range sortedKeys(schema.Domains) {
+		add(objectID(KindDomain, name), statement(schema.Domains[name].Statement))
+	}
+	for _, seq := range schema.Sequences {
+		add(objectID(KindSequence, seq.Name), statement(seq.Statement))
+	}
+
+	tablePublications := publicationNames(schema.Publications, tables)
+	for _, tableName := range tableNames {
+		tableName := tableName
+		add(objectID(KindTable, tableName), func() {
+			printTableObjects(os.Stdout, tableName, tables[tableName], tablePublications[tableName], deferred[tableName], options)
+		})
+	}
+	// partition index attachments follow once the indexes of all tables exist
+	var attachments []string
+	for _, tableName := range tableNames {
+		for _, index := range tables[tableName].Index {
+			attachments = append(attachments, index.AttachStatements()...)
 		}
-		if i < len(tableNames)-1 {
-			fmt.Println()
+	}
+	if len(attachments) > 0 {
+		add(objectID(kindAttachments, ""), statement(strings.Join(attachments, "\n")))
+	}
+	// foreign keys deferred until the tables they reference exist
+	var foreignKeys []string
+	for _, tableName := range tableNames {
+		for _, name := range sortedKeys(deferred[tableName]) {
+			foreignKeys = append(foreignKeys, fmt.Sprintf("ALTER TABLE %s ADD %s;", tableName,
+				tables[tableName].Constraints[name].Statement))
 		}
 	}
-	fmt.Println()
+	if len(foreignKeys) > 0 {
+		add(objectID(kindForeignKeys, ""), statement(strings.Join(foreignKeys, "\n")))
+	}
+
+	for _, signature := range sortFunctions(schema.Functions, false) {
+		add(objectID(KindFunction, signature), statement(schema.Functions[signature].Statement))
+	}
+	for _, signature := range sortFunctions(schema.Functions, true) {
+		add(objectID(KindProcedure, signature), statement(schema.Functions[signature].Statement))
+	}
+	// aggregates, operators and casts are built from functions
+	for _, signature := range sortedKeys(schema.Aggregates) {
+		add(objectID(KindAggregate, signature), statement(schema.Aggregates[signature].Statement))
+	}
+	for _, signature := range sortedKeys(schema.Operators) {
+		add(objectID(KindOperator, signature), statement(schema.Operators[signature].Statement))
+	}
+	for _, signature := range sortedKeys(schema.Casts) {
+		add(objectID(KindCast, signature), statement(schema.Casts[signature].Statement))
+	}
+
+	for _, name := range sortedKeys(schema.Wrappers) {
+		add(objectID(KindWrapper, name), statement(schema.Wrappers[name].Statement))
+	}
+	for _, name := range sortedKeys(schema.Servers) {
+		add(objectID(KindServer, name), statement(schema.Servers[name].Statement))
+	}
+	for _, key := range sortedKeys(schema.UserMappings) {
+		add(objectID(KindUserMapping, key), statement(schema.UserMappings[key].Statement(options.ShowCredentials)))
+	}
+	for _, name := range sortedKeys(schema.ForeignTables) {
+		add(objectID(KindForeignTable, name), statement(schema.ForeignTables[name].Statement()))
+	}
+
+	for _, name := range sortedKeys(schema.Views) {
+		view := schema.Views[name]
+		add(objectID(KindView, name), statement(strings.Join(append([]string{view.Statement}, view.Rules...), "\n")))
+	}
 
-	// print functions
-	for _, f := range functions {
-		fmt.Println(f)
+	for _, name := range sortedKeys(schema.Publications) {
+		add(objectID(KindPublication, name), statement(strings.Join(schema.Publications[name].Statements(), "\n")))
+	}
+	for _, name := range sortedKeys(schema.Subscriptions) {
+		add(objectID(KindSubscription, name), statement(schema.Subscriptions[name].Statement(options.ShowCredentials)))
+	}
+	for _, name := range sortedKeys(schema.EventTriggers) {
+		add(objectID(KindEventTrigger, name), statement(schema.EventTriggers[name].Statement))
+	}
+	for _, trigger := range schema.Triggers {
+		add(objectID(KindTrigger, trigger.Table+"."+trigger.Name), statement(trigger.Statement))
 	}
-	fmt.Println()
 
-	// print triggers
-	for _, tr := range triggers {
-		fmt.Println(tr)
+	// print objects in order, moving objects ahead where their dependencies require
+	previous := ""
+	for _, id := range dependencyGraph(schema, tableNames, deferred).SortInOrder(order) {
+		printObject, ok := printers[id]
+		if !ok {
+			continue
+		}
+		kind := objectKind(id)
+		section, ok := printSections[kind]
+		if !ok {
+			section = kind
+		}
+		if len(previous) > 0 && (section != previous || blockKinds[kind]) {
+			fmt.Println()
+		}
+		printObject()
+		previous = section
 	}
+	return nil
 }
 
 // ReadLine is a wrapper around bufio's Reader ReadLine that returns only the line and a boolean indicating eof
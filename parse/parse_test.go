@@ -69,20 +69,45 @@ func TestMapTables(t *testing.T) {
 	}
 	expectedTable1 := &Table{
 		Columns: map[string]*Column{
-			"col1": {Statement: "varchar"},
-			"col2": {Statement: "string"},
+			"col1": {Statement: "varchar", Position: 1},
+			"col2": {Statement: "string", Position: 2},
 		},
+		Position: 1,
+	}
+	table3 := []string{
+		"CREATE TABLE public.table3 (",
+		"col1 varchar,",
+		"CONSTRAINT col1_check CHECK ((public.valid(col1)))",
+		")",
+		"WITH (fillfactor='70');",
+	}
+	expectedTable2 := &Table{Position: 1}
+	expectedTable3 := &Table{
+		Columns: map[string]*Column{
+			"col1": {Statement: "varchar", Position: 1},
+		},
+		Constraints: map[string]*Constraint{
+			"col1_check": {
+				Name:       "col1_check",
+				Kind:       CheckConstraint,
+				Expression: "(valid(col1))",
+				Statement:  "CONSTRAINT col1_check CHECK ((valid(col1)))",
+			},
+		},
+		StorageParameters: []string{"fillfactor='70'"},
+		Position:          1,
+		Schema:            "public",
 	}
-	expectedTable2 := &Table{}
 	expectedTablesMap1 := map[string]*Table{"table1": expectedTable1}
 	expectedTablesMap2 := map[string]*Table{"table2": expectedTable2}
-	expectedTablesMap3 := map[string]*Table{"table1": expectedTable1, "table2": expectedTable2}
+	expectedTablesMap3 := map[string]*Table{"table1": expectedTable1, "table2": {Position: 2}}
 
 	tests := []struct {
 		name           string
 		input          []string
 		expectedTables map[string]*Table
 		expectedLines  []string
+		expectedError  error
 	}{
 		{
 			name:           "No input",
@@ -108,16 +133,31 @@ func TestMapTables(t *testing.T) {
 			expectedTables: expectedTablesMap2,
 			expectedLines:  []string{},
 		},
+		{
+			name:           "Table with constraints and storage parameters",
+			input:          table3,
+			expectedTables: map[string]*Table{"table3": expectedTable3},
+			expectedLines:  []string{},
+		},
 		{
 			name:           "Table statements with extra lines",
 			input:          append(append(append([]string{""}, table1...), []string{"", ""}...), table2...),
 			expectedTables: expectedTablesMap3,
 			expectedLines:  []string{"", "", ""},
 		},
+		{
+			name:           "Invalid inline constraint",
+			input:          []string{"CREATE TABLE table4 (", "col1 varchar,", "CONSTRAINT col1_fkey FOREIGN KEY (col1)", ");"},
+			expectedTables: map[string]*Table{},
+			expectedLines:  []string{"CREATE TABLE table4 (", "col1 varchar,", "CONSTRAINT col1_fkey FOREIGN KEY (col1)", ");"},
+			expectedError:  fmt.Errorf("mapping tables - invalid constraint"),
+		},
 	}
 	for _, test := range tests {
-		tables, lines := MapTables(test.input)
-		if !similarTables(tables, test.expectedTables) {
+		tables, lines, err := MapTables(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !similarTables(tables, test.expectedTables) {
 			t.Error(test.name + " - tables error")
 		} else if !similarLines(lines, test.expectedLines) {
 			t.Error(test.name + " - lines error")
@@ -214,6 +254,107 @@ func TestMapSequences(t *testing.T) {
 	}
 }
 
+func TestOrderColumns(t *testing.T) {
+	table := &Table{
+		Columns: map[string]*Column{
+			"name":     {Statement: "text", Position: 1},
+			"id":       {Statement: "integer", Position: 3, IsPrimaryKey: true},
+			"owner_id": {Statement: "integer", Position: 2, IsForeignKey: true},
+			"age":      {Statement: "integer", Position: 4},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "Grouped",
+			input:    ColumnOrderGrouped,
+			expected: []string{"id", "owner_id", "age", "name"},
+		},
+		{
+			name:     "Physical",
+			input:    ColumnOrderPhysical,
+			expected: []string{"name", "owner_id", "id", "age"},
+		},
+		{
+			name:     "Alphabetical",
+			input:    ColumnOrderAlphabetical,
+			expected: []string{"age", "id", "name", "owner_id"},
+		},
+	}
+	for _, test := range tests {
+		if !similarLines(orderColumns(table, test.input), test.expected) {
+			t.Error(test.name + " - columns error")
+		}
+	}
+}
+
+func TestSimplifyCreateSequenceStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Default options",
+			input:    "CREATE SEQUENCE seq START WITH 1 INCREMENT BY 1 NO MINVALUE NO MAXVALUE CACHE 1;",
+			expected: "CREATE SEQUENCE seq;",
+		},
+		{
+			name:     "Options with longer values",
+			input:    "CREATE SEQUENCE seq START WITH 1000 INCREMENT BY 10 NO MINVALUE NO MAXVALUE CACHE 10;",
+			expected: "CREATE SEQUENCE seq START WITH 1000 INCREMENT BY 10 CACHE 10;",
+		},
+	}
+	for _, test := range tests {
+		if stmt := simplifyCreateSequenceStatement(test.input); stmt != test.expected {
+			t.Error(test.name + " - statement error")
+		}
+	}
+}
+
+func TestStoreSequences(t *testing.T) {
+	tests := []struct {
+		name              string
+		input             []string
+		expectedSequences []*IndependentSequence
+		expectedLines     []string
+	}{
+		{
+			name:              "No input",
+			input:             []string{},
+			expectedSequences: nil,
+			expectedLines:     []string{},
+		},
+		{
+			name: "Sequence statements with extra lines",
+			input: []string{
+				"abc",
+				"CREATE SEQUENCE public.invoice_numbers START WITH 1 INCREMENT BY 1 NO MINVALUE NO MAXVALUE CACHE 1;",
+				"CREATE SEQUENCE ticket_number START WITH 1 INCREMENT BY 1 NO MINVALUE NO MAXVALUE CACHE 1;",
+			},
+			expectedSequences: []*IndependentSequence{
+				{Name: "invoice_numbers", Schema: "public", Statement: "CREATE SEQUENCE invoice_numbers;"},
+				{Name: "ticket_number", Statement: "CREATE SEQUENCE ticket_number;"},
+			},
+			expectedLines: []string{"abc"},
+		},
+	}
+	for _, test := range tests {
+		lines, seqs, err := StoreSequences(test.input)
+		if err != nil {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(seqs, test.expectedSequences) {
+			t.Error(test.name + " - sequences error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
+
 func TestMapDefaultValues(t *testing.T) {
 	inputTable1 := &Table{
 		Columns: map[string]*Column{
@@ -340,19 +481,19 @@ func TestMapConstraints(t *testing.T) {
 		Columns: map[string]*Column{
 			"id": {Statement: "col id"},
 		},
-		Constraints: make(map[string]string),
+		Constraints: make(map[string]*Constraint),
 	}
 	inputTable2 := &Table{
 		Columns: map[string]*Column{
 			"id": {Statement: "col id"},
 		},
-		Constraints: make(map[string]string),
+		Constraints: make(map[string]*Constraint),
 	}
 	inputTable3 := &Table{
 		Columns: map[string]*Column{
 			"id": {Statement: "col id"},
 		},
-		Constraints: make(map[string]string),
+		Constraints: make(map[string]*Constraint),
 	}
 	expectedTable1 := &Table{
 		Columns: map[string]*Column{
@@ -361,8 +502,13 @@ func TestMapConstraints(t *testing.T) {
 				IsPrimaryKey: true,
 			},
 		},
-		Constraints: map[string]string{
-			"table_pkey": "CONSTRAINT table_pkey PRIMARY KEY (id)",
+		Constraints: map[string]*Constraint{
+			"table_pkey": {
+				Name:      "table_pkey",
+				Kind:      PrimaryKeyConstraint,
+				Columns:   []string{"id"},
+				Statement: "CONSTRAINT table_pkey PRIMARY KEY (id)",
+			},
 		},
 	}
 	expectedTable2 := &Table{
@@ -372,8 +518,16 @@ func TestMapConstraints(t *testing.T) {
 				IsForeignKey: true,
 			},
 		},
-		Constraints: map[string]string{
-			"table_fkey": "CONSTRAINT table_fkey FOREIGN KEY (id) REFERENCES table2(id) ON DELETE CASCADE",
+		Constraints: map[string]*Constraint{
+			"table_fkey": {
+				Name:       "table_fkey",
+				Kind:       ForeignKeyConstraint,
+				Columns:    []string{"id"},
+				RefTable:   "table2",
+				RefColumns: []string{"id"},
+				OnDelete:   "CASCADE",
+				Statement:  "CONSTRAINT table_fkey FOREIGN KEY (id) REFERENCES table2(id) ON DELETE CASCADE",
+			},
 		},
 	}
 	expectedTable3 := &Table{
@@ -383,8 +537,13 @@ func TestMapConstraints(t *testing.T) {
 				IsPrimaryKey: true,
 			},
 		},
-		Constraints: map[string]string{
-			"table_pkey": "CONSTRAINT table_pkey PRIMARY KEY (id)",
+		Constraints: map[string]*Constraint{
+			"table_pkey": {
+				Name:      "table_pkey",
+				Kind:      PrimaryKeyConstraint,
+				Columns:   []string{"id"},
+				Statement: "CONSTRAINT table_pkey PRIMARY KEY (id)",
+			},
 		},
 	}
 	inputTablesMap1 := map[string]*Table{"table1": inputTable1}
@@ -429,18 +588,26 @@ func TestMapConstraints(t *testing.T) {
 		{
 			name:           "Column does not exist - primary key",
 			inputLines:     []string{"ALTER TABLE ONLY table1 ADD CONSTRAINT table_pkey PRIMARY KEY (id);"},
-			inputTables:    map[string]*Table{"table1": {Constraints: make(map[string]string)}},
-			expectedTables: map[string]*Table{"table1": {Constraints: make(map[string]string)}},
+			inputTables:    map[string]*Table{"table1": {Constraints: make(map[string]*Constraint)}},
+			expectedTables: map[string]*Table{"table1": {Constraints: make(map[string]*Constraint)}},
 			expectedLines:  []string{"ALTER TABLE ONLY table1 ADD CONSTRAINT table_pkey PRIMARY KEY (id);"},
 			expectedError:  fmt.Errorf("mapping constraints - column does not exist"),
 		},
 		{
 			name:           "Column does not exist - foreign key",
-			inputLines:     []string{"ALTER TABLE ONLY table1 ADD CONSTRAINT table_pkey FOREIGN KEY (id);"},
-			inputTables:    map[string]*Table{"table1": {Constraints: make(map[string]string)}},
-			expectedTables: map[string]*Table{"table1": {Constraints: make(map[string]string)}},
-			expectedLines:  []string{"ALTER TABLE ONLY table1 ADD CONSTRAINT table_pkey FOREIGN KEY (id);"},
-			expectedError:  fmt.Errorf("column does not exist"),
+			inputLines:     []string{"ALTER TABLE ONLY table1 ADD CONSTRAINT table_fkey FOREIGN KEY (id) REFERENCES table2(id);"},
+			inputTables:    map[string]*Table{"table1": {Constraints: make(map[string]*Constraint)}},
+			expectedTables: map[string]*Table{"table1": {Constraints: make(map[string]*Constraint)}},
+			expectedLines:  []string{"ALTER TABLE ONLY table1 ADD CONSTRAINT table_fkey FOREIGN KEY (id) REFERENCES table2(id);"},
+			expectedError:  fmt.Errorf("mapping constraints - column does not exist"),
+		},
+		{
+			name:           "Foreign key without referenced table",
+			inputLines:     []string{"ALTER TABLE ONLY table1 ADD CONSTRAINT table_fkey FOREIGN KEY (id);"},
+			inputTables:    map[string]*Table{"table1": {Constraints: make(map[string]*Constraint)}},
+			expectedTables: map[string]*Table{"table1": {Constraints: make(map[string]*Constraint)}},
+			expectedLines:  []string{"ALTER TABLE ONLY table1 ADD CONSTRAINT table_fkey FOREIGN KEY (id);"},
+			expectedError:  fmt.Errorf("mapping constraints - invalid constraint"),
 		},
 		{
 			name:           "Primary key constraint",
@@ -484,8 +651,20 @@ func TestMapConstraints(t *testing.T) {
 func TestMapIndices(t *testing.T) {
 	inputTable1 := &Table{}
 	inputTable2 := &Table{}
-	expectedTable1 := &Table{Index: []string{"CREATE UNIQUE INDEX user_idx ON table1 USING btree (username);"}}
-	expectedTable2 := &Table{Index: []string{"CREATE UNIQUE INDEX user_idx ON table2 USING btree (username);"}}
+	expectedTable1 := &Table{Index: []*Index{{
+		Name:     "user_idx",
+		Table:    "table1",
+		IsUnique: true,
+		Method:   "btree",
+		Keys:     []*IndexKey{{Expression: "username"}},
+	}}}
+	expectedTable2 := &Table{Index: []*Index{{
+		Name:     "user_idx",
+		Table:    "table2",
+		IsUnique: true,
+		Method:   "btree",
+		Keys:     []*IndexKey{{Expression: "username"}},
+	}}}
 	inputTablesMap1 := map[string]*Table{"table1": inputTable1}
 	expectedTablesMap1 := map[string]*Table{"table1": expectedTable1}
 	inputTablesMap2 := map[string]*Table{"table2": inputTable2}
@@ -523,6 +702,14 @@ func TestMapIndices(t *testing.T) {
 			expectedLines:  []string{"CREATE UNIQUE INDEX user_idx ON table1 USING btree (username);"},
 			expectedError:  fmt.Errorf("mapping indices - table does not exist"),
 		},
+		{
+			name:           "Replica identity using index",
+			inputLines:     []string{"ALTER TABLE ONLY table1 REPLICA IDENTITY USING INDEX user_idx;"},
+			inputTables:    map[string]*Table{"table1": {}},
+			expectedTables: map[string]*Table{"table1": {}},
+			expectedLines:  []string{"ALTER TABLE ONLY table1 REPLICA IDENTITY USING INDEX user_idx;"},
+			expectedError:  nil,
+		},
 		{
 			name:           "Create index",
 			inputLines:     []string{"CREATE UNIQUE INDEX user_idx ON table1 USING btree (username);"},
@@ -552,6 +739,53 @@ func TestMapIndices(t *testing.T) {
 	}
 }
 
+func TestStoreTriggers(t *testing.T) {
+	trigger := "CREATE TRIGGER users_audit AFTER UPDATE ON public.users FOR EACH ROW EXECUTE FUNCTION public.audit();"
+	constraintTrigger := "CREATE CONSTRAINT TRIGGER users_check AFTER INSERT ON users DEFERRABLE FOR EACH ROW EXECUTE FUNCTION check_user();"
+
+	tests := []struct {
+		name             string
+		input            []string
+		expectedTriggers []*Trigger
+		expectedLines    []string
+	}{
+		{
+			name:             "No input",
+			input:            []string{},
+			expectedTriggers: nil,
+			expectedLines:    []string{},
+		},
+		{
+			name:  "Trigger statements with extra lines",
+			input: []string{"abc", trigger, constraintTrigger, "def"},
+			expectedTriggers: []*Trigger{
+				{
+					Name:      "users_audit",
+					Table:     "users",
+					Schema:    "public",
+					Statement: "CREATE TRIGGER users_audit AFTER UPDATE ON users FOR EACH ROW EXECUTE FUNCTION audit();",
+				},
+				{
+					Name:      "users_check",
+					Table:     "users",
+					Statement: constraintTrigger,
+				},
+			},
+			expectedLines: []string{"abc", "def"},
+		},
+	}
+	for _, test := range tests {
+		lines, triggers, err := StoreTriggers(test.input)
+		if err != nil {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(triggers, test.expectedTriggers) {
+			t.Error(test.name + " - triggers error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
+
 func TestSquashMultiLineStatements(t *testing.T) {
 	input1 := []string{"abc", "def", "ghi;"}
 	expected1 := []string{"abc def ghi;"}
@@ -587,6 +821,40 @@ func TestSquashMultiLineStatements(t *testing.T) {
 	}
 }
 
+func TestIsDeepEqual(t *testing.T) {
+	table := &Table{
+		Columns:  map[string]*Column{"id": {Statement: "integer", Position: 1}, "name": {Statement: "text", Position: 2}},
+		Position: 1,
+	}
+	tests := []struct {
+		name     string
+		input    *Table
+		expected bool
+	}{
+		{
+			name: "Different positions",
+			input: &Table{
+				Columns:  map[string]*Column{"id": {Statement: "integer", Position: 2}, "name": {Statement: "text", Position: 1}},
+				Position: 3,
+			},
+			expected: true,
+		},
+		{
+			name: "Different columns",
+			input: &Table{
+				Columns:  map[string]*Column{"id": {Statement: "bigint", Position: 1}, "name": {Statement: "text", Position: 2}},
+				Position: 1,
+			},
+			expected: false,
+		},
+	}
+	for _, test := range tests {
+		if table.IsDeepEqual(test.input) != test.expected {
+			t.Error(test.name)
+		}
+	}
+}
+
 func similarTables(tables1, tables2 map[string]*Table) bool {
 	if len(tables1) != len(tables2) {
 		return false
@@ -597,9 +865,15 @@ func similarTables(tables1, tables2 map[string]*Table) bool {
 		table2 := tables2[name]
 		if table1 == nil && table2 == nil {
 			continue
-		} else if table1 == nil || table2 == nil || !table1.IsDeepEqual(table2) {
+		} else if table1 == nil || table2 == nil || !table1.IsDeepEqual(table2) || table1.Position != table2.Position {
 			return false
 		}
+		// positions are ignored by IsDeepEqual but set when storing tables and columns
+		for name, column := range table1.Columns {
+			if column.Position != table2.Columns[name].Position {
+				return false
+			}
+		}
 	}
 	return true
 }
@@ -0,0 +1,248 @@
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PublicationTable is the struct containing a table's membership of a publication
+type PublicationTable struct {
+	Name    string
+	Columns []string
+	Where   string
+}
+
+// Publication is the struct containing logical aspects of a logical replication publication
+type Publication struct {
+	Name      string
+	AllTables bool
+	Tables    []*PublicationTable
+	Schemas   []string
+	Options   map[string]string
+	Statement string
+}
+
+// Subscription is the struct containing logical aspects of a logical replication subscription
+type Subscription struct {
+	Name         string
+	Connection   string
+	Publications []string
+	Options      map[string]string
+	Create       string
+}
+
+// connectionPasswordExp matches the password in a libpq connection string
+var connectionPasswordExp = regexp.MustCompile(`password\s*=\s*(''|[^\s'])+`)
+
+// Statements returns the create statement of the publication followed by the statements adding its tables and
+// schemas
+func (p *Publication) Statements() []string {
+	stmts := []string{p.Statement}
+	for _, table := range p.Tables {
+		stmt := "ALTER PUBLICATION " + p.Name + " ADD TABLE ONLY " + table.Name
+		if len(table.Columns) > 0 {
+			stmt += " (" + strings.Join(table.Columns, ", ") + ")"
+		}
+		if len(table.Where) > 0 {
+			stmt += " WHERE " + table.Where
+		}
+		stmts = append(stmts, stmt+";")
+	}
+	for _, schema := range p.Schemas {
+		stmts = append(stmts, "ALTER PUBLICATION "+p.Name+" ADD TABLES IN SCHEMA "+schema+";")
+	}
+	return stmts
+}
+
+// Statement returns the create statement of the subscription, redacting the connection password unless
+// showCredentials is set
+func (s *Subscription) Statement(showCredentials bool) string {
+	if showCredentials {
+		return s.Create
+	}
+	redacted := connectionPasswordExp.ReplaceAllString(s.Connection, "password=********")
+	return strings.Replace(s.Create, s.Connection, redacted, 1)
+}
+
+// publicationNames returns the names of the publications each table belongs to, by table or by schema
+func publicationNames(publications map[string]*Publication, tables map[string]*Table) map[string][]string {
+	names := make(map[string][]string)
+	for name, publication := range publications {
+		schemas := make(map[string]bool)
+		for _, schema := range publication.Schemas {
+			schemas[schema] = true
+		}
+		added := make(map[string]bool)
+		for tableName, table := range tables {
+			if publication.AllTables || schemas[table.Schema] {
+				added[tableName] = true
+			}
+		}
+		for _, table := range publication.Tables {
+			added[table.Name] = true
+		}
+		for tableName := range added {
+			names[tableName] = append(names[tableName], name)
+		}
+	}
+	for _, tableNames := range names {
+		sort.Strings(tableNames)
+	}
+	return names
+}
+
+// parsePublicationTable parses a table of a publication with its optional column list and row filter
+func parsePublicationTable(definition string) *PublicationTable {
+	definition = strings.TrimPrefix(strings.TrimPrefix(definition, "TABLE "), "ONLY ")
+
+	member := &PublicationTable{}
+	tokens := tokenize(definition)
+	member.Name, _ = removeAccessModifier(strings.TrimSuffix(tokens[0], "*"))
+	if index := strings.Index(tokens[0], "("); index != -1 {
+		member.Name, _ = removeAccessModifier(tokens[0][:index])
+		member.Columns = splitTopLevel(tokens[0][index+1:len(tokens[0])-1], ',')
+	} else if len(tokens) > 1 && strings.HasPrefix(tokens[1], "(") {
+		member.Columns = splitTopLevel(tokens[1][1:len(tokens[1])-1], ',')
+	}
+	if index := indexKeyword(definition, "WHERE"); index != -1 {
+		member.Where = strings.TrimSpace(definition[index+len("WHERE"):])
+	}
+	return member
+}
+
+// storePublicationObjects parses the tables and schemas listed after FOR in a create publication statement into
+// the publication and removes them from its statement, which leaves them to be added by its alter statements
+func storePublicationObjects(publication *Publication, tables map[string]*Table) error {
+	line := publication.Statement
+	index := indexKeyword(line, "FOR")
+	if index == -1 || publication.AllTables {
+		return nil
+	}
+	end := len(strings.TrimSuffix(line, ";"))
+	if withIndex := indexKeyword(line, "WITH"); withIndex > index {
+		end = withIndex
+	}
+
+	inSchemas := false
+	for _, object := range splitTopLevel(line[index+len("FOR"):end], ',') {
+		if strings.HasPrefix(object, "TABLES IN SCHEMA ") {
+			inSchemas = true
+			object = object[len("TABLES IN SCHEMA "):]
+		} else if strings.HasPrefix(object, "TABLE ") {
+			inSchemas = false
+		}
+		if inSchemas {
+			publication.Schemas = append(publication.Schemas, object)
+			continue
+		}
+		member := parsePublicationTable(object)
+		if _, ok := tables[member.Name]; !ok {
+			return fmt.Errorf("storing publications - table does not exist")
+		}
+		publication.Tables = append(publication.Tables, member)
+	}
+
+	publication.Statement = strings.TrimSpace(line[:index])
+	if end < len(strings.TrimSuffix(line, ";")) {
+		publication.Statement += " " + line[end:]
+	} else {
+		publication.Statement += ";"
+	}
+	return nil
+}
+
+// StorePublications parses sql statements for publications and the tables added to them and maps them by name.
+// The tables added to publications must exist in tables. Tables and schemas listed in create statements are stored
+// like those added by alter statements.
+// It then returns the remaining lines and publications.
+func StorePublications(lines []string, tables map[string]*Table) ([]string, map[string]*Publication, error) {
+	publications := make(map[string]*Publication)
+	if len(lines) == 0 {
+		return lines, publications, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "CREATE PUBLICATION ") {
+			publication := &Publication{
+				Name:      strings.TrimSuffix(strings.Split(line, " ")[2], ";"),
+				AllTables: strings.Contains(line, " FOR ALL TABLES"),
+				Options:   make(map[string]string),
+				Statement: line,
+			}
+			if index := indexKeyword(line, "WITH"); index != -1 {
+				publication.Options = parseOptions(line[index:])
+			}
+			if err := storePublicationObjects(publication, tables); err != nil {
+				return lines, publications, err
+			}
+			publications[publication.Name] = publication
+		} else if strings.HasPrefix(line, "ALTER PUBLICATION ") && strings.Contains(line, " ADD ") {
+			tokens := strings.Split(strings.TrimSuffix(line, ";"), " ")
+			publication, ok := publications[tokens[2]]
+			if !ok {
+				return lines, publications, fmt.Errorf("storing publications - publication does not exist")
+			}
+
+			rest := strings.TrimSuffix(line[strings.Index(line, " ADD ")+len(" ADD "):], ";")
+			if strings.HasPrefix(rest, "TABLES IN SCHEMA ") {
+				publication.Schemas = append(publication.Schemas, rest[len("TABLES IN SCHEMA "):])
+				continue
+			}
+			member := parsePublicationTable(rest)
+			if _, ok := tables[member.Name]; !ok {
+				return lines, publications, fmt.Errorf("storing publications - table does not exist")
+			}
+
+			publication.Tables = append(publication.Tables, member)
+		} else {
+			bufferLines = append(bufferLines, line)
+		}
+	}
+
+	return bufferLines, publications, nil
+}
+
+// StoreSubscriptions parses sql statements for subscriptions and maps them by name.
+// It then returns the remaining lines and subscriptions.
+func StoreSubscriptions(lines []string) ([]string, map[string]*Subscription, error) {
+	subscriptions := make(map[string]*Subscription)
+	if len(lines) == 0 {
+		return lines, subscriptions, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE SUBSCRIPTION ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		subscription := &Subscription{
+			Name:       strings.Split(line, " ")[2],
+			Connection: keywordValue(line, "CONNECTION"),
+			Options:    make(map[string]string),
+			Create:     line,
+		}
+		if len(subscription.Connection) == 0 {
+			return lines, subscriptions, fmt.Errorf("storing subscriptions - missing connection")
+		}
+
+		index := indexKeyword(line, "PUBLICATION")
+		if index == -1 {
+			return lines, subscriptions, fmt.Errorf("storing subscriptions - missing publication")
+		}
+		rest := strings.TrimSuffix(line[index+len("PUBLICATION"):], ";")
+		if withIndex := indexKeyword(rest, "WITH"); withIndex != -1 {
+			subscription.Options = parseOptions(rest[withIndex:])
+			rest = rest[:withIndex]
+		}
+		subscription.Publications = splitTopLevel(rest, ',')
+
+		subscriptions[subscription.Name] = subscription
+	}
+
+	return bufferLines, subscriptions, nil
+}
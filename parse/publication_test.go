@@ -0,0 +1,205 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStorePublications(t *testing.T) {
+	publication := "CREATE PUBLICATION cdc WITH (publish = 'insert, update, delete');"
+	tables := map[string]*Table{"orders": {}, "customers": {}}
+	expectedPublication := &Publication{
+		Name:    "cdc",
+		Options: map[string]string{"publish": "'insert, update, delete'"},
+		Tables: []*PublicationTable{
+			{Name: "orders"},
+			{Name: "customers", Columns: []string{"id", "email"}, Where: "((email IS NOT NULL))"},
+		},
+		Schemas:   []string{"audit"},
+		Statement: publication,
+	}
+
+	tests := []struct {
+		name                 string
+		inputLines           []string
+		inputTables          map[string]*Table
+		expectedPublications map[string]*Publication
+		expectedLines        []string
+		expectedError        error
+	}{
+		{
+			name:                 "No input",
+			inputLines:           []string{},
+			inputTables:          tables,
+			expectedPublications: map[string]*Publication{},
+			expectedLines:        []string{},
+			expectedError:        nil,
+		},
+		{
+			name:                 "Publication does not exist",
+			inputLines:           []string{"ALTER PUBLICATION cdc ADD TABLE ONLY public.orders;"},
+			inputTables:          tables,
+			expectedPublications: map[string]*Publication{},
+			expectedLines:        []string{"ALTER PUBLICATION cdc ADD TABLE ONLY public.orders;"},
+			expectedError:        fmt.Errorf("storing publications - publication does not exist"),
+		},
+		{
+			name:        "Table does not exist",
+			inputLines:  []string{publication, "ALTER PUBLICATION cdc ADD TABLE ONLY public.invoices;"},
+			inputTables: tables,
+			expectedPublications: map[string]*Publication{
+				"cdc": {Name: "cdc", Options: map[string]string{"publish": "'insert, update, delete'"}, Statement: publication},
+			},
+			expectedLines: []string{publication, "ALTER PUBLICATION cdc ADD TABLE ONLY public.invoices;"},
+			expectedError: fmt.Errorf("storing publications - table does not exist"),
+		},
+		{
+			name: "Publication statements with extra lines",
+			inputLines: []string{
+				"abc",
+				publication,
+				"ALTER PUBLICATION cdc ADD TABLE ONLY public.orders;",
+				"ALTER PUBLICATION cdc ADD TABLE ONLY public.customers (id, email) WHERE ((email IS NOT NULL));",
+				"ALTER PUBLICATION cdc ADD TABLES IN SCHEMA audit;",
+				"def",
+			},
+			inputTables:          tables,
+			expectedPublications: map[string]*Publication{"cdc": expectedPublication},
+			expectedLines:        []string{"abc", "def"},
+			expectedError:        nil,
+		},
+		{
+			name: "Publication for tables and schemas",
+			inputLines: []string{
+				"CREATE PUBLICATION sales FOR TABLE ONLY public.orders, public.customers (id, email) WHERE ((email IS NOT NULL)), TABLES IN SCHEMA audit, billing WITH (publish = 'insert');",
+				"CREATE PUBLICATION orders_only FOR TABLE public.orders;",
+			},
+			inputTables: tables,
+			expectedPublications: map[string]*Publication{
+				"sales": {
+					Name:    "sales",
+					Options: map[string]string{"publish": "'insert'"},
+					Tables: []*PublicationTable{
+						{Name: "orders"},
+						{Name: "customers", Columns: []string{"id", "email"}, Where: "((email IS NOT NULL))"},
+					},
+					Schemas:   []string{"audit", "billing"},
+					Statement: "CREATE PUBLICATION sales WITH (publish = 'insert');",
+				},
+				"orders_only": {
+					Name:      "orders_only",
+					Options:   map[string]string{},
+					Tables:    []*PublicationTable{{Name: "orders"}},
+					Statement: "CREATE PUBLICATION orders_only;",
+				},
+			},
+			expectedLines: []string{},
+			expectedError: nil,
+		},
+		{
+			name:                 "Publication for table that does not exist",
+			inputLines:           []string{"CREATE PUBLICATION sales FOR TABLE public.invoices;"},
+			inputTables:          tables,
+			expectedPublications: map[string]*Publication{},
+			expectedLines:        []string{"CREATE PUBLICATION sales FOR TABLE public.invoices;"},
+			expectedError:        fmt.Errorf("storing publications - table does not exist"),
+		},
+	}
+	for _, test := range tests {
+		lines, publications, err := StorePublications(test.inputLines, test.inputTables)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(publications, test.expectedPublications) {
+			t.Error(test.name + " - publications error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+
+	expectedStatements := []string{
+		publication,
+		"ALTER PUBLICATION cdc ADD TABLE ONLY orders;",
+		"ALTER PUBLICATION cdc ADD TABLE ONLY customers (id, email) WHERE ((email IS NOT NULL));",
+		"ALTER PUBLICATION cdc ADD TABLES IN SCHEMA audit;",
+	}
+	if !cmp.Equal(expectedPublication.Statements(), expectedStatements) {
+		t.Error("Publication statements error")
+	}
+
+	allTables := map[string]*Publication{"everything": {Name: "everything", AllTables: true}, "cdc": expectedPublication}
+	expectedNames := map[string][]string{"orders": {"cdc", "everything"}, "customers": {"cdc", "everything"}}
+	if !cmp.Equal(publicationNames(allTables, tables), expectedNames) {
+		t.Error("Publication names error")
+	}
+
+	schemaTables := map[string]*Table{"orders": {Schema: "public"}, "logins": {Schema: "audit"}}
+	bySchema := map[string]*Publication{
+		"cdc":   expectedPublication,
+		"audit": {Name: "audit", Schemas: []string{"audit"}, Tables: []*PublicationTable{{Name: "orders"}}},
+	}
+	expectedNames = map[string][]string{"orders": {"audit", "cdc"}, "customers": {"cdc"}, "logins": {"audit", "cdc"}}
+	if !cmp.Equal(publicationNames(bySchema, schemaTables), expectedNames) {
+		t.Error("Publication names by schema error")
+	}
+}
+
+func TestStoreSubscriptions(t *testing.T) {
+	subscription := "CREATE SUBSCRIPTION replica CONNECTION 'host=primary password=hunter2 dbname=app' PUBLICATION cdc, audit WITH (connect = false, slot_name = 'replica');"
+	expectedSubscription := &Subscription{
+		Name:         "replica",
+		Connection:   "'host=primary password=hunter2 dbname=app'",
+		Publications: []string{"cdc", "audit"},
+		Options:      map[string]string{"connect": "false", "slot_name": "'replica'"},
+		Create:       subscription,
+	}
+
+	tests := []struct {
+		name                  string
+		input                 []string
+		expectedSubscriptions map[string]*Subscription
+		expectedLines         []string
+		expectedError         error
+	}{
+		{
+			name:                  "No input",
+			input:                 []string{},
+			expectedSubscriptions: map[string]*Subscription{},
+			expectedLines:         []string{},
+			expectedError:         nil,
+		},
+		{
+			name:                  "Missing publication",
+			input:                 []string{"CREATE SUBSCRIPTION replica CONNECTION 'host=primary';"},
+			expectedSubscriptions: map[string]*Subscription{},
+			expectedLines:         []string{"CREATE SUBSCRIPTION replica CONNECTION 'host=primary';"},
+			expectedError:         fmt.Errorf("storing subscriptions - missing publication"),
+		},
+		{
+			name:                  "Subscription statements with extra lines",
+			input:                 []string{"abc", subscription, "def"},
+			expectedSubscriptions: map[string]*Subscription{"replica": expectedSubscription},
+			expectedLines:         []string{"abc", "def"},
+			expectedError:         nil,
+		},
+	}
+	for _, test := range tests {
+		lines, subscriptions, err := StoreSubscriptions(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(subscriptions, test.expectedSubscriptions) {
+			t.Error(test.name + " - subscriptions error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+
+	redacted := "CREATE SUBSCRIPTION replica CONNECTION 'host=primary password=******** dbname=app' PUBLICATION cdc, audit WITH (connect = false, slot_name = 'replica');"
+	if expectedSubscription.Statement(false) != redacted {
+		t.Error("Redacted subscription statement error")
+	}
+	if expectedSubscription.Statement(true) != subscription {
+		t.Error("Subscription statement error")
+	}
+}
@@ -0,0 +1,194 @@
+package parse
+
+import (
+	"bufio"
+	"fmt"
+)
+
+// ReadSchema reads a schema dump and processes its statements into a schema.
+// It returns the unprocessed lines along with an error if any statement cannot be processed.
+func ReadSchema(reader *bufio.Reader) ([]string, *Schema, error) {
+	var lines []string
+	// read file by line till EOF
+	for {
+		line, eof := ReadLine(reader)
+		if eof {
+			break
+		}
+
+		// 1. Check for redundant lines
+		if IsRedundant(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	// 2. Store functions and procedures before multi-line bodies are mistaken for other statements
+	lines, functions, err := StoreFunctions(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 3. Store views before multi-line queries are squashed
+	lines, views, err := StoreViews(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 4. Group and map table statements
+	tables, lines, err := MapTables(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 5. Squash any multi-line statements to single line
+	lines = SquashMultiLineStatements(lines)
+
+	// 6. Squash sequence statements into create sequence statements and map to tables
+	lines, err = MapSequences(lines, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 7. Store sequences not owned by table columns
+	lines, seqs, err := StoreSequences(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 8. Store types, domains, collations, aggregates, operators, casts and text search objects
+	lines, types, err := StoreTypes(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, domains, err := StoreDomains(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, collations, err := StoreCollations(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, aggregates, err := StoreAggregates(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, operators, err := StoreOperators(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, casts, err := StoreCasts(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines, dictionaries, err := StoreTextSearchDictionaries(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, configs, err := StoreTextSearchConfigurations(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 9. Map rules and statistics to tables and views and store event triggers
+	lines, err = StoreRules(lines, tables, views)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, err = MapStatistics(lines, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, eventTriggers, err := StoreEventTriggers(lines, functions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 10. Store foreign data wrappers, servers, user mappings and foreign tables
+	lines, wrappers, err := StoreForeignDataWrappers(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, servers, err := StoreForeignServers(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, userMappings, err := StoreUserMappings(lines, servers)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, foreignTables, err := StoreForeignTables(lines, servers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 11. Store publications with their tables and subscriptions
+	lines, publications, err := StorePublications(lines, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, subscriptions, err := StoreSubscriptions(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 12. Map replica identities, cluster indices, storage parameters and column attributes to tables
+	lines, err = MapTableAttributes(lines, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 13. Add default values to columns
+	lines, err = MapDefaultValues(lines, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 14. Map constraint statements to tables
+	lines, err = MapConstraints(lines, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 15. Map index statements to tables
+	lines, err = MapIndices(lines, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 16. Store triggers and trigger functions
+	lines, triggers, err := StoreTriggers(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(lines) != 0 {
+		return lines, nil, fmt.Errorf("%d unprocessed lines remaining", len(lines))
+	}
+
+	schema := &Schema{
+		Tables:        tables,
+		Sequences:     seqs,
+		Functions:     functions,
+		Triggers:      triggers,
+		Types:         types,
+		Domains:       domains,
+		Aggregates:    aggregates,
+		Operators:     operators,
+		Casts:         casts,
+		Collations:    collations,
+		Dictionaries:  dictionaries,
+		Configs:       configs,
+		Views:         views,
+		EventTriggers: eventTriggers,
+		Wrappers:      wrappers,
+		Servers:       servers,
+		UserMappings:  userMappings,
+		ForeignTables: foreignTables,
+		Publications:  publications,
+		Subscriptions: subscriptions,
+	}
+
+	return nil, schema, nil
+}
@@ -0,0 +1,56 @@
+package parse
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadSchema(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		expectedTables []string
+		expectedLines  []string
+		hasError       bool
+	}{
+		{
+			name: "Tables and constraints",
+			input: "-- comment\nCREATE TABLE public.users (\n    id integer NOT NULL\n);\n\n" +
+				"ALTER TABLE ONLY public.users\n    ADD CONSTRAINT users_pkey PRIMARY KEY (id);\n",
+			expectedTables: []string{"users"},
+		},
+		{
+			name:          "Unprocessed lines",
+			input:         "CREATE TABLE public.users (\n    id integer NOT NULL\n);\n\nLOCK TABLE public.users;\n",
+			expectedLines: []string{"LOCK TABLE public.users;"},
+			hasError:      true,
+		},
+	}
+	for _, test := range tests {
+		lines, schema, err := ReadSchema(bufio.NewReader(strings.NewReader(test.input)))
+		if test.hasError {
+			if err == nil || !cmp.Equal(lines, test.expectedLines) {
+				t.Error(test.name + " - error expected")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(test.name + " - unexpected error")
+		} else if !cmp.Equal(sortedKeys(schema.Tables), test.expectedTables) {
+			t.Error(test.name + " - tables error")
+		}
+	}
+}
+
+// readTestSchema reads the schema from the dump, failing the test if it cannot be read
+func readTestSchema(t *testing.T, input string) *Schema {
+	t.Helper()
+	_, schema, err := ReadSchema(bufio.NewReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
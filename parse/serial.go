@@ -0,0 +1,74 @@
+package parse
+
+import (
+	"strings"
+)
+
+// serialTypes maps the integer types to the serial types creating a sequence of the same type
+var serialTypes = map[string]string{
+	"smallint": "smallserial",
+	"integer":  "serial",
+	"bigint":   "bigserial",
+}
+
+// serialType returns the column owning seq and the serial type that creates the sequence and the column default, or
+// empty strings if the sequence is not in the form created by a serial column
+func serialType(tableName string, table *Table, seq *Sequence) (string, string) {
+	// ALTER SEQUENCE name OWNED BY table.column;
+	tokens := strings.Split(strings.TrimSuffix(seq.Relation, ";"), " ")
+	if len(tokens) != 6 {
+		return "", ""
+	}
+	seqName := tokens[2]
+	columnName := tokens[5][strings.LastIndex(tokens[5], ".")+1:]
+	column, ok := table.Columns[columnName]
+	if !ok || seqName != tableName+"_"+columnName+"_seq" {
+		return "", ""
+	}
+
+	typ, rest := splitColumnStatement(column.Statement)
+	serial, ok := serialTypes[normaliseType(typ, TypeStyleStandard)]
+	if !ok || !strings.Contains(rest, "NOT NULL") || !strings.Contains(rest, serialDefault(seqName)) {
+		return "", ""
+	}
+
+	// the sequence must only set options left to their defaults by serial columns
+	options := strings.TrimSuffix(strings.TrimPrefix(seq.Create, "CREATE SEQUENCE "+seqName), ";")
+	if options = strings.TrimSpace(options); len(options) > 0 &&
+		options != "AS "+normaliseType(typ, TypeStyleStandard) {
+		return "", ""
+	}
+	return columnName, serial
+}
+
+func serialDefault(seqName string) string {
+	return "DEFAULT nextval('" + seqName + "'::regclass)"
+}
+
+// collapseSerials returns the serial types of the columns of the table whose owned sequences and defaults can be
+// declared with serial types, and the remaining sequences which cannot
+func collapseSerials(tableName string, table *Table) (map[string]string, []*Sequence) {
+	serials := make(map[string]string)
+	var sequences []*Sequence
+	for _, seq := range table.Sequences {
+		if columnName, serial := serialType(tableName, table, seq); len(serial) > 0 {
+			serials[columnName] = serial
+		} else {
+			sequences = append(sequences, seq)
+		}
+	}
+	return serials, sequences
+}
+
+// serialStatement returns the statement of the column declared with the serial type instead of its integer type and
+// sequence default
+func serialStatement(column *Column, serial string) string {
+	_, rest := splitColumnStatement(column.Statement)
+	index := strings.Index(rest, "DEFAULT nextval(")
+	end := strings.Index(rest[index:], "::regclass)") + index + len("::regclass)")
+	rest = strings.Join(strings.Fields(rest[:index]+rest[end:]), " ")
+	if len(rest) == 0 {
+		return serial
+	}
+	return serial + " " + rest
+}
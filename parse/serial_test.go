@@ -0,0 +1,65 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCollapseSerials(t *testing.T) {
+	newTable := func(create string) *Table {
+		return &Table{
+			Columns: map[string]*Column{
+				"id": {Statement: "integer NOT NULL DEFAULT nextval('orders_id_seq'::regclass)"},
+			},
+			Sequences: []*Sequence{
+				{Create: create, Relation: "ALTER SEQUENCE orders_id_seq OWNED BY orders.id;"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		input             *Table
+		expectedSerials   map[string]string
+		expectedSequences int
+	}{
+		{
+			name:              "Default sequence",
+			input:             newTable("CREATE SEQUENCE orders_id_seq AS integer;"),
+			expectedSerials:   map[string]string{"id": "serial"},
+			expectedSequences: 0,
+		},
+		{
+			name:              "Sequence without type",
+			input:             newTable("CREATE SEQUENCE orders_id_seq;"),
+			expectedSerials:   map[string]string{"id": "serial"},
+			expectedSequences: 0,
+		},
+		{
+			name:              "Sequence with options",
+			input:             newTable("CREATE SEQUENCE orders_id_seq AS integer START WITH 10;"),
+			expectedSerials:   map[string]string{},
+			expectedSequences: 1,
+		},
+		{
+			name:              "Sequence of different type",
+			input:             newTable("CREATE SEQUENCE orders_id_seq AS bigint;"),
+			expectedSerials:   map[string]string{},
+			expectedSequences: 1,
+		},
+	}
+	for _, test := range tests {
+		serials, sequences := collapseSerials("orders", test.input)
+		if !cmp.Equal(serials, test.expectedSerials) {
+			t.Error(test.name + " - serials error")
+		} else if len(sequences) != test.expectedSequences {
+			t.Error(test.name + " - sequences error")
+		}
+	}
+
+	column := &Column{Statement: "bigint NOT NULL DEFAULT nextval('orders_id_seq'::regclass)"}
+	if serialStatement(column, "bigserial") != "bigserial NOT NULL" {
+		t.Error("Serial statement error")
+	}
+}
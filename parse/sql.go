@@ -0,0 +1,189 @@
+package parse
+
+import (
+	"strings"
+)
+
+// dollarTagAt returns the dollar quote tag (e.g. "$$" or "$body$") starting at index i of s, or an empty string if
+// there is none
+func dollarTagAt(s string, i int) string {
+	if i >= len(s) || s[i] != '$' {
+		return ""
+	}
+	for j := i + 1; j < len(s); j++ {
+		c := s[j]
+		if c == '$' {
+			return s[i : j+1]
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || j > i+1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+// skipQuoted returns the index just after the quoted section (single quotes, double quotes or dollar quotes) starting
+// at index i of s. If s[i] does not start a quoted section, i is returned unchanged. If the quoted section is not
+// terminated, len(s) is returned.
+func skipQuoted(s string, i int) int {
+	switch s[i] {
+	case '\'', '"':
+		quote := s[i]
+		for j := i + 1; j < len(s); j++ {
+			if s[j] == quote {
+				// doubled quotes are escaped quotes
+				if j+1 < len(s) && s[j+1] == quote {
+					j++
+					continue
+				}
+				return j + 1
+			}
+		}
+		return len(s)
+	case '$':
+		tag := dollarTagAt(s, i)
+		if len(tag) == 0 {
+			return i
+		}
+		end := strings.Index(s[i+len(tag):], tag)
+		if end == -1 {
+			return len(s)
+		}
+		return i + len(tag) + end + len(tag)
+	}
+	return i
+}
+
+// statementEnd returns the index of the semicolon terminating the statement in s, ignoring semicolons in quoted
+// sections, or -1 if the statement is not terminated
+func statementEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		if j := skipQuoted(s, i); j != i {
+			i = j - 1
+			continue
+		}
+		if s[i] == ';' {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchingParen returns the index of the parenthesis closing the one at index open of s, or -1 if there is none
+func matchingParen(s string, open int) int {
+	if open < 0 {
+		return -1
+	}
+	depth := 0
+	for i := open; i < len(s); i++ {
+		if j := skipQuoted(s, i); j != i {
+			i = j - 1
+			continue
+		}
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits s by sep, ignoring separators in parentheses or quoted sections, and trims each element
+func splitTopLevel(s string, sep byte) []string {
+	var elements []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		if j := skipQuoted(s, i); j != i {
+			i = j - 1
+			continue
+		}
+		switch s[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case sep:
+			if depth == 0 {
+				elements = append(elements, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); len(rest) > 0 || len(elements) > 0 {
+		elements = append(elements, rest)
+	}
+	return elements
+}
+
+// tokenize splits s by whitespace, keeping parenthesised and quoted sections within a single token
+func tokenize(s string) []string {
+	var tokens []string
+	depth, start := 0, -1
+	for i := 0; i < len(s); i++ {
+		if j := skipQuoted(s, i); j != i {
+			if start == -1 {
+				start = i
+			}
+			i = j - 1
+			continue
+		}
+		c := s[i]
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (c == ' ' || c == '\t' || c == '\n'):
+			if start != -1 {
+				tokens = append(tokens, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start == -1 {
+			start = i
+		}
+	}
+	if start != -1 {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// indexKeyword returns the index of the first occurrence of keyword in s as a whole word outside parentheses and
+// quoted sections, or -1 if there is none
+func indexKeyword(s, keyword string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		if j := skipQuoted(s, i); j != i {
+			i = j - 1
+			continue
+		}
+		switch s[i] {
+		case '(':
+			depth++
+			continue
+		case ')':
+			depth--
+			continue
+		}
+		if depth == 0 && strings.HasPrefix(s[i:], keyword) && isWordBoundary(s, i-1) &&
+			isWordBoundary(s, i+len(keyword)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isWordBoundary(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return true
+	}
+	c := s[i]
+	return !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+}
@@ -0,0 +1,90 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Statistics is the struct containing logical aspects of an extended statistics object
+type Statistics struct {
+	Name        string
+	Kinds       []string
+	Expressions []string
+	Target      string
+}
+
+// Statements returns the create statement of the statistics object on table, followed by the statement setting its
+// statistics target if any
+func (s *Statistics) Statements(table string) []string {
+	stmt := "CREATE STATISTICS " + s.Name
+	if len(s.Kinds) > 0 {
+		stmt += " (" + strings.Join(s.Kinds, ", ") + ")"
+	}
+	stmts := []string{stmt + " ON " + strings.Join(s.Expressions, ", ") + " FROM " + table + ";"}
+	if len(s.Target) > 0 {
+		stmts = append(stmts, "ALTER STATISTICS "+s.Name+" SET STATISTICS "+s.Target+";")
+	}
+	return stmts
+}
+
+// findStatistics returns the statistics object with the given name from any of the tables
+func findStatistics(name string, tables map[string]*Table) (*Statistics, bool) {
+	for _, table := range tables {
+		for _, statistics := range table.Statistics {
+			if statistics.Name == name {
+				return statistics, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// MapStatistics parses sql statements for extended statistics and maps them to their tables.
+// It then returns the remaining lines.
+func MapStatistics(lines []string, tables map[string]*Table) ([]string, error) {
+	if len(lines) == 0 {
+		return lines, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "ALTER STATISTICS ") && strings.Contains(line, " SET STATISTICS ") {
+			name, _ := removeAccessModifier(strings.Split(line, " ")[2])
+			statistics, ok := findStatistics(name, tables)
+			if !ok {
+				return lines, fmt.Errorf("mapping statistics - statistics does not exist")
+			}
+			statistics.Target = strings.TrimSuffix(keywordValue(line, "SET STATISTICS"), ";")
+			continue
+		} else if !strings.HasPrefix(line, "CREATE STATISTICS ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		stmt := strings.TrimSuffix(line[len("CREATE STATISTICS "):], ";")
+		onIndex := indexKeyword(stmt, "ON")
+		fromIndex := indexKeyword(stmt, "FROM")
+		if onIndex == -1 || fromIndex == -1 || fromIndex < onIndex {
+			return lines, fmt.Errorf("mapping statistics - missing statistics columns")
+		}
+
+		statistics := &Statistics{
+			Expressions: splitTopLevel(stmt[onIndex+len("ON"):fromIndex], ','),
+		}
+		header := strings.TrimSpace(stmt[:onIndex])
+		if open := strings.Index(header, "("); open != -1 {
+			statistics.Kinds = splitTopLevel(header[open+1:len(header)-1], ',')
+			header = strings.TrimSpace(header[:open])
+		}
+		statistics.Name, _ = removeAccessModifier(header)
+
+		tableName, _ := removeAccessModifier(strings.TrimSpace(stmt[fromIndex+len("FROM"):]))
+		table, ok := tables[tableName]
+		if !ok {
+			return lines, fmt.Errorf("mapping statistics - table does not exist")
+		}
+		table.Statistics = append(table.Statistics, statistics)
+	}
+
+	return bufferLines, nil
+}
@@ -0,0 +1,87 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMapStatistics(t *testing.T) {
+	statistics := "CREATE STATISTICS public.orders_stats (dependencies, ndistinct) ON customer_id, status FROM public.orders;"
+	expressionStatistics := "CREATE STATISTICS public.orders_lower ON lower(status), customer_id FROM public.orders;"
+	expectedTable := &Table{
+		Statistics: []*Statistics{
+			{
+				Name:        "orders_stats",
+				Kinds:       []string{"dependencies", "ndistinct"},
+				Expressions: []string{"customer_id", "status"},
+				Target:      "1000",
+			},
+			{
+				Name:        "orders_lower",
+				Expressions: []string{"lower(status)", "customer_id"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		inputLines     []string
+		inputTables    map[string]*Table
+		expectedTables map[string]*Table
+		expectedLines  []string
+		expectedError  error
+	}{
+		{
+			name:           "No input",
+			inputLines:     []string{},
+			inputTables:    map[string]*Table{"orders": {}},
+			expectedTables: map[string]*Table{"orders": {}},
+			expectedLines:  []string{},
+			expectedError:  nil,
+		},
+		{
+			name:           "Table does not exist",
+			inputLines:     []string{statistics},
+			inputTables:    map[string]*Table{"customers": {}},
+			expectedTables: map[string]*Table{"customers": {}},
+			expectedLines:  []string{statistics},
+			expectedError:  fmt.Errorf("mapping statistics - table does not exist"),
+		},
+		{
+			name:           "Statistics does not exist",
+			inputLines:     []string{"ALTER STATISTICS public.orders_stats SET STATISTICS 1000;"},
+			inputTables:    map[string]*Table{"orders": {}},
+			expectedTables: map[string]*Table{"orders": {}},
+			expectedLines:  []string{"ALTER STATISTICS public.orders_stats SET STATISTICS 1000;"},
+			expectedError:  fmt.Errorf("mapping statistics - statistics does not exist"),
+		},
+		{
+			name:           "Statistics statements with extra lines",
+			inputLines:     []string{"abc", statistics, expressionStatistics, "ALTER STATISTICS public.orders_stats SET STATISTICS 1000;", "def"},
+			inputTables:    map[string]*Table{"orders": {}},
+			expectedTables: map[string]*Table{"orders": expectedTable},
+			expectedLines:  []string{"abc", "def"},
+			expectedError:  nil,
+		},
+	}
+	for _, test := range tests {
+		lines, err := MapStatistics(test.inputLines, test.inputTables)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !similarTables(test.inputTables, test.expectedTables) {
+			t.Error(test.name + " - tables error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+
+	expectedStatements := []string{
+		"CREATE STATISTICS orders_stats (dependencies, ndistinct) ON customer_id, status FROM orders;",
+		"ALTER STATISTICS orders_stats SET STATISTICS 1000;",
+	}
+	if !cmp.Equal(expectedTable.Statistics[0].Statements("orders"), expectedStatements) {
+		t.Error("Statistics statements error")
+	}
+}
@@ -0,0 +1,59 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SubsetSchema reduces the schema to the root tables and the tables they transitively reference by foreign keys, so
+// the subset can be loaded on its own. Tables referencing the subset are added up to referencingHops hops away, along
+// with the tables they reference in turn.
+// The tables keep their sequences, constraints, indexes and triggers, and only the objects they depend on, such as
+// trigger functions, types, domains, collations and sequences, are kept among the other objects.
+func SubsetSchema(schema *Schema, roots []string, referencingHops int) error {
+	var rootNames []string
+	for _, root := range roots {
+		name, _ := removeAccessModifier(strings.TrimSpace(root))
+		if _, ok := schema.Tables[name]; !ok {
+			return fmt.Errorf("subsetting schema - table %s does not exist", name)
+		}
+		rootNames = append(rootNames, name)
+	}
+	if len(rootNames) == 0 {
+		return fmt.Errorf("subsetting schema - missing root tables")
+	}
+
+	g := tableGraph(schema.Tables)
+	names := g.Closure(rootNames...)
+	for hop := 0; hop < referencingHops; hop++ {
+		names = g.Closure(g.Dependents(1, names...)...)
+	}
+	subset := make(map[string]bool)
+	for _, name := range names {
+		subset[name] = true
+	}
+
+	removed := make(map[string]bool)
+	for name := range schema.Tables {
+		if !subset[name] {
+			removed[name] = true
+		}
+	}
+	removeTables(schema, removed)
+
+	// keep the objects the tables and their triggers need
+	ids := make([]string, 0, len(schema.Tables)+len(schema.Triggers))
+	for name := range schema.Tables {
+		ids = append(ids, objectID(KindTable, name))
+	}
+	for _, trigger := range schema.Triggers {
+		ids = append(ids, objectID(KindTrigger, trigger.Table+"."+trigger.Name))
+	}
+	tableNames, deferred, _ := orderTables(schema.Tables, TableOrderAlphabetical, false)
+	keep := make(map[string]bool)
+	for _, id := range dependencyGraph(schema, tableNames, deferred).Closure(ids...) {
+		keep[id] = true
+	}
+	retainObjects(schema, keep)
+	return nil
+}
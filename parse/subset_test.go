@@ -0,0 +1,117 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSubsetSchema(t *testing.T) {
+	newSchema := func() *Schema {
+		return &Schema{
+			Tables: map[string]*Table{
+				"users": {Schema: "public", Columns: map[string]*Column{
+					"name": {Statement: "text COLLATE public.names"},
+				}},
+				"orders": {Schema: "public", Columns: map[string]*Column{
+					"ticket": {Statement: "integer DEFAULT nextval('public.ticket_number'::regclass)"},
+				}, Constraints: map[string]*Constraint{
+					"orders_user_id_fkey": {Kind: ForeignKeyConstraint, RefTable: "users"},
+				}},
+				"items": {Schema: "public", Constraints: map[string]*Constraint{
+					"items_order_id_fkey": {Kind: ForeignKeyConstraint, RefTable: "orders"},
+				}},
+				"item_notes": {Schema: "public", Constraints: map[string]*Constraint{
+					"item_notes_item_id_fkey":   {Kind: ForeignKeyConstraint, RefTable: "items"},
+					"item_notes_author_id_fkey": {Kind: ForeignKeyConstraint, RefTable: "authors"},
+				}},
+				"authors": {Schema: "public"},
+				"other":   {Schema: "public"},
+			},
+			Sequences: []*IndependentSequence{
+				{Name: "ticket_number", Schema: "public", Statement: "CREATE SEQUENCE ticket_number;"},
+				{Name: "unused", Schema: "public", Statement: "CREATE SEQUENCE unused;"},
+			},
+			Functions: map[string]*Function{
+				"touch()":  {Name: "touch"},
+				"unused()": {Name: "unused"},
+			},
+			Triggers: []*Trigger{
+				{Name: "orders_touch", Table: "orders",
+					Statement: "CREATE TRIGGER orders_touch BEFORE UPDATE ON orders FOR EACH ROW EXECUTE FUNCTION touch();"},
+				{Name: "other_touch", Table: "other",
+					Statement: "CREATE TRIGGER other_touch BEFORE UPDATE ON other FOR EACH ROW EXECUTE FUNCTION unused();"},
+			},
+			Collations: map[string]*Collation{
+				"names": {Name: "names", Schema: "public"},
+				"other": {Name: "other", Schema: "public"},
+			},
+			Views: map[string]*View{"public.v": {Name: "v", Schema: "public"}},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		roots              []string
+		referencingHops    int
+		expectedTables     []string
+		expectedSequences  int
+		expectedFunctions  []string
+		expectedCollations []string
+		hasError           bool
+	}{
+		{
+			name:               "Referenced tables",
+			roots:              []string{"public.orders"},
+			expectedTables:     []string{"orders", "users"},
+			expectedSequences:  1,
+			expectedFunctions:  []string{"touch()"},
+			expectedCollations: []string{"names"},
+		},
+		{
+			name:               "Referencing tables",
+			roots:              []string{"orders"},
+			referencingHops:    2,
+			expectedTables:     []string{"authors", "item_notes", "items", "orders", "users"},
+			expectedSequences:  1,
+			expectedFunctions:  []string{"touch()"},
+			expectedCollations: []string{"names"},
+		},
+		{
+			name:               "Leaf table",
+			roots:              []string{"other"},
+			expectedTables:     []string{"other"},
+			expectedSequences:  0,
+			expectedFunctions:  []string{"unused()"},
+			expectedCollations: []string{},
+		},
+		{
+			name:     "Missing table",
+			roots:    []string{"missing"},
+			hasError: true,
+		},
+	}
+	for _, test := range tests {
+		schema := newSchema()
+		err := SubsetSchema(schema, test.roots, test.referencingHops)
+		if test.hasError {
+			if err == nil {
+				t.Error(test.name + " - error expected")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(test.name + " - unexpected error")
+		} else if !cmp.Equal(sortedKeys(schema.Tables), test.expectedTables) {
+			t.Error(test.name + " - tables error")
+		} else if len(schema.Sequences) != test.expectedSequences {
+			t.Error(test.name + " - sequences error")
+		} else if !cmp.Equal(sortedKeys(schema.Functions), test.expectedFunctions) {
+			t.Error(test.name + " - functions error")
+		} else if !cmp.Equal(sortedKeys(schema.Collations), test.expectedCollations) {
+			t.Error(test.name + " - collations error")
+		} else if len(schema.Views) != 0 || len(schema.Triggers) != len(test.expectedFunctions) {
+			t.Error(test.name + " - objects error")
+		}
+	}
+}
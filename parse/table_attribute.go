@@ -0,0 +1,171 @@
+package parse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// tableClauseKeywords are the keywords starting the clauses that can follow the column list of a create table statement
+var tableClauseKeywords = map[string]bool{
+	"INHERITS":   true,
+	"PARTITION":  true,
+	"USING":      true,
+	"WITH":       true,
+	"WITHOUT":    true,
+	"TABLESPACE": true,
+	"ON":         true,
+}
+
+// parseTableClauses parses the clauses following the column list of a create table statement into its storage
+// parameters and remaining clauses
+func parseTableClauses(suffix string) ([]string, []string) {
+	var parameters, clauses []string
+	for _, token := range tokenize(suffix) {
+		if tableClauseKeywords[token] || len(clauses) == 0 {
+			clauses = append(clauses, token)
+		} else {
+			clauses[len(clauses)-1] += " " + token
+		}
+	}
+
+	var bufferClauses []string
+	for _, clause := range clauses {
+		if strings.HasPrefix(clause, "WITH (") {
+			parameters = setParameters(parameters, splitTopLevel(clause[len("WITH ("):len(clause)-1], ','))
+		} else {
+			bufferClauses = append(bufferClauses, clause)
+		}
+	}
+	return parameters, bufferClauses
+}
+
+// parameterName returns the name of a "name=value" parameter
+func parameterName(parameter string) string {
+	return strings.TrimSpace(strings.Split(parameter, "=")[0])
+}
+
+// setParameters sets each "name=value" parameter of updates in parameters, replacing any parameter of the same name
+func setParameters(parameters, updates []string) []string {
+	for _, update := range updates {
+		name := parameterName(update)
+		replaced := false
+		for i, parameter := range parameters {
+			if parameterName(parameter) == name {
+				parameters[i] = update
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			parameters = append(parameters, update)
+		}
+	}
+	return parameters
+}
+
+// AttributeStatements returns the alter statements setting the column attributes, cluster index and replica identity
+// of the table
+func (t *Table) AttributeStatements(tableName string) []string {
+	var stmts []string
+	columnNames := make([]string, 0, len(t.Columns))
+	for name := range t.Columns {
+		columnNames = append(columnNames, name)
+	}
+	sort.Strings(columnNames)
+
+	for _, name := range columnNames {
+		column := t.Columns[name]
+		prefix := "ALTER TABLE ONLY " + tableName + " ALTER COLUMN " + name
+		if len(column.Statistics) > 0 {
+			stmts = append(stmts, prefix+" SET STATISTICS "+column.Statistics+";")
+		}
+		if len(column.Storage) > 0 {
+			stmts = append(stmts, prefix+" SET STORAGE "+column.Storage+";")
+		}
+		if len(column.Options) > 0 {
+			stmts = append(stmts, prefix+" SET ("+strings.Join(column.Options, ", ")+");")
+		}
+	}
+	if len(t.ClusterOn) > 0 {
+		stmts = append(stmts, "ALTER TABLE "+tableName+" CLUSTER ON "+t.ClusterOn+";")
+	}
+	if len(t.ReplicaIdentity) > 0 {
+		stmts = append(stmts, "ALTER TABLE ONLY "+tableName+" REPLICA IDENTITY "+t.ReplicaIdentity+";")
+	}
+	return stmts
+}
+
+// MapTableAttributes parses alter table statements for replica identities, cluster indices, storage parameters and
+// column statistics, storage and options and maps them to their tables and columns.
+// It then returns the remaining lines.
+func MapTableAttributes(lines []string, tables map[string]*Table) ([]string, error) {
+	if len(lines) == 0 {
+		return lines, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "ALTER TABLE ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		tokens := strings.Split(strings.TrimSuffix(line, ";"), " ")
+		i := 2
+		if tokens[i] == "ONLY" {
+			i++
+		}
+		if len(tokens) < i+2 {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+		tableName, _ := removeAccessModifier(tokens[i])
+		action := strings.Join(tokens[i+1:], " ")
+
+		var columnName, columnAction string
+		if strings.HasPrefix(action, "ALTER COLUMN ") && len(tokens) > i+3 {
+			columnName = tokens[i+3]
+			columnAction = strings.Join(tokens[i+4:], " ")
+		}
+		isTableAttribute := strings.HasPrefix(action, "REPLICA IDENTITY ") ||
+			strings.HasPrefix(action, "CLUSTER ON ") || strings.HasPrefix(action, "SET (")
+		isColumnAttribute := strings.HasPrefix(columnAction, "SET STATISTICS ") ||
+			strings.HasPrefix(columnAction, "SET STORAGE ") || strings.HasPrefix(columnAction, "SET (")
+		if !isTableAttribute && !isColumnAttribute {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		table, ok := tables[tableName]
+		if !ok {
+			return lines, fmt.Errorf("mapping table attributes - table does not exist")
+		}
+
+		switch {
+		case strings.HasPrefix(action, "REPLICA IDENTITY "):
+			table.ReplicaIdentity = action[len("REPLICA IDENTITY "):]
+		case strings.HasPrefix(action, "CLUSTER ON "):
+			table.ClusterOn = action[len("CLUSTER ON "):]
+		case strings.HasPrefix(action, "SET ("):
+			table.StorageParameters = setParameters(table.StorageParameters,
+				splitTopLevel(action[len("SET ("):len(action)-1], ','))
+		default:
+			column, ok := table.Columns[columnName]
+			if !ok {
+				return lines, fmt.Errorf("mapping table attributes - column does not exist")
+			}
+			switch {
+			case strings.HasPrefix(columnAction, "SET STATISTICS "):
+				column.Statistics = columnAction[len("SET STATISTICS "):]
+			case strings.HasPrefix(columnAction, "SET STORAGE "):
+				column.Storage = columnAction[len("SET STORAGE "):]
+			default:
+				column.Options = setParameters(column.Options,
+					splitTopLevel(columnAction[len("SET ("):len(columnAction)-1], ','))
+			}
+		}
+	}
+
+	return bufferLines, nil
+}
@@ -0,0 +1,120 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMapTableAttributes(t *testing.T) {
+	newTable := func() *Table {
+		return &Table{
+			Columns: map[string]*Column{
+				"id":     {Statement: "integer"},
+				"status": {Statement: "text"},
+			},
+			StorageParameters: []string{"fillfactor='70'"},
+		}
+	}
+	expectedTable := &Table{
+		Columns: map[string]*Column{
+			"id": {Statement: "integer", Storage: "PLAIN"},
+			"status": {
+				Statement:  "text",
+				Statistics: "500",
+				Options:    []string{"n_distinct=100"},
+			},
+		},
+		StorageParameters: []string{"fillfactor='80'", "autovacuum_enabled='false'"},
+		ClusterOn:         "table1_status_idx",
+		ReplicaIdentity:   "FULL",
+	}
+
+	tests := []struct {
+		name           string
+		inputLines     []string
+		inputTables    map[string]*Table
+		expectedTables map[string]*Table
+		expectedLines  []string
+		expectedError  error
+	}{
+		{
+			name:           "No input",
+			inputLines:     []string{},
+			inputTables:    map[string]*Table{"table1": newTable()},
+			expectedTables: map[string]*Table{"table1": newTable()},
+			expectedLines:  []string{},
+			expectedError:  nil,
+		},
+		{
+			name:           "Table does not exist",
+			inputLines:     []string{"ALTER TABLE ONLY public.table2 REPLICA IDENTITY FULL;"},
+			inputTables:    map[string]*Table{"table1": newTable()},
+			expectedTables: map[string]*Table{"table1": newTable()},
+			expectedLines:  []string{"ALTER TABLE ONLY public.table2 REPLICA IDENTITY FULL;"},
+			expectedError:  fmt.Errorf("mapping table attributes - table does not exist"),
+		},
+		{
+			name:           "Column does not exist",
+			inputLines:     []string{"ALTER TABLE ONLY public.table1 ALTER COLUMN name SET STATISTICS 500;"},
+			inputTables:    map[string]*Table{"table1": newTable()},
+			expectedTables: map[string]*Table{"table1": newTable()},
+			expectedLines:  []string{"ALTER TABLE ONLY public.table1 ALTER COLUMN name SET STATISTICS 500;"},
+			expectedError:  fmt.Errorf("mapping table attributes - column does not exist"),
+		},
+		{
+			name: "Table attribute statements with extra lines",
+			inputLines: []string{
+				"abc",
+				"ALTER TABLE ONLY public.table1 ALTER COLUMN status SET STATISTICS 500;",
+				"ALTER TABLE ONLY public.table1 ALTER COLUMN id SET STORAGE PLAIN;",
+				"ALTER TABLE ONLY public.table1 ALTER COLUMN status SET (n_distinct=100);",
+				"ALTER TABLE ONLY public.table1 ALTER COLUMN id SET DEFAULT nextval('seq'::regclass);",
+				"ALTER TABLE ONLY public.table1 SET (fillfactor='80', autovacuum_enabled='false');",
+				"ALTER TABLE public.table1 CLUSTER ON table1_status_idx;",
+				"ALTER TABLE ONLY public.table1 REPLICA IDENTITY FULL;",
+				"def",
+			},
+			inputTables:    map[string]*Table{"table1": newTable()},
+			expectedTables: map[string]*Table{"table1": expectedTable},
+			expectedLines: []string{
+				"abc",
+				"ALTER TABLE ONLY public.table1 ALTER COLUMN id SET DEFAULT nextval('seq'::regclass);",
+				"def",
+			},
+			expectedError: nil,
+		},
+	}
+	for _, test := range tests {
+		lines, err := MapTableAttributes(test.inputLines, test.inputTables)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !similarTables(test.inputTables, test.expectedTables) {
+			t.Error(test.name + " - tables error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+
+	expectedStatements := []string{
+		"ALTER TABLE ONLY table1 ALTER COLUMN id SET STORAGE PLAIN;",
+		"ALTER TABLE ONLY table1 ALTER COLUMN status SET STATISTICS 500;",
+		"ALTER TABLE ONLY table1 ALTER COLUMN status SET (n_distinct=100);",
+		"ALTER TABLE table1 CLUSTER ON table1_status_idx;",
+		"ALTER TABLE ONLY table1 REPLICA IDENTITY FULL;",
+	}
+	if !cmp.Equal(expectedTable.AttributeStatements("table1"), expectedStatements) {
+		t.Error("Table attribute statements error")
+	}
+}
+
+func TestParseTableClauses(t *testing.T) {
+	parameters, clauses := parseTableClauses("INHERITS (parent) WITH (fillfactor='70', autovacuum_enabled='false') TABLESPACE fast")
+	if !cmp.Equal(parameters, []string{"fillfactor='70'", "autovacuum_enabled='false'"}) {
+		t.Error("Table clauses - parameters error")
+	}
+	if !cmp.Equal(clauses, []string{"INHERITS (parent)", "TABLESPACE fast"}) {
+		t.Error("Table clauses - clauses error")
+	}
+}
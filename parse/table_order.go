@@ -0,0 +1,103 @@
+package parse
+
+import (
+	"sort"
+
+	"github.com/jchiam/psql-schema-dump-sanitiser/graph"
+)
+
+// Table orders tables can be printed in
+const (
+	TableOrderTopological  = "topological"
+	TableOrderAlphabetical = "alphabetical"
+	TableOrderDump         = "dump"
+	TableOrderComponent    = "component"
+)
+
+// tableNames returns the names of the tables in alphabetical order
+func tableNames(tables map[string]*Table) []string {
+	return sortedKeys(tables)
+}
+
+// tableGraph returns the graph of the tables with edges from tables to the tables they reference by foreign keys,
+// labelled with the names of the foreign key constraints. References to tables which are not printed are left out.
+func tableGraph(tables map[string]*Table) *graph.Graph {
+	g := graph.New()
+	for name, table := range tables {
+		g.AddNode(name)
+		for constraintName, constraint := range table.Constraints {
+			if _, ok := tables[constraint.RefTable]; ok && constraint.Kind == ForeignKeyConstraint {
+				g.AddEdge(name, constraint.RefTable, constraintName)
+			}
+		}
+	}
+	return g
+}
+
+// tableComponents groups the tables connected by foreign keys. The tables of each component are in alphabetical order
+// and the components are ordered by their first table.
+func tableComponents(tables map[string]*Table) [][]string {
+	return tableGraph(tables).Components()
+}
+
+// deferredForeignKeys returns the names of the foreign key constraints of each table which must be added after all
+// tables are created, as they reference tables printed after their own. If all is set, every foreign key referencing
+// another table is deferred.
+func deferredForeignKeys(tableNames []string, tables map[string]*Table, all bool) map[string]map[string]bool {
+	positions := make(map[string]int)
+	for i, name := range tableNames {
+		positions[name] = i
+	}
+
+	deferred := make(map[string]map[string]bool)
+	for i, name := range tableNames {
+		for constraintName, constraint := range tables[name].Constraints {
+			if constraint.Kind != ForeignKeyConstraint || constraint.RefTable == name {
+				continue
+			}
+			if position, ok := positions[constraint.RefTable]; all || ok && position > i {
+				if deferred[name] == nil {
+					deferred[name] = make(map[string]bool)
+				}
+				deferred[name][constraintName] = true
+			}
+		}
+	}
+	return deferred
+}
+
+// orderTables returns the names of the tables in the given table order and the foreign key constraints of each table
+// deferred until all tables are created. Foreign key cycles are broken if breakCycles is set.
+func orderTables(tables map[string]*Table, order string, breakCycles bool) ([]string, map[string]map[string]bool,
+	error) {
+	switch order {
+	case TableOrderAlphabetical:
+		names := tableNames(tables)
+		return names, deferredForeignKeys(names, tables, true), nil
+	case TableOrderDump:
+		names := tableNames(tables)
+		sort.SliceStable(names, func(i, j int) bool {
+			return tables[names[i]].Position < tables[names[j]].Position
+		})
+		return names, deferredForeignKeys(names, tables, false), nil
+	case TableOrderComponent:
+		var names []string
+		for _, component := range tableComponents(tables) {
+			componentTables := make(map[string]*Table)
+			for _, name := range component {
+				componentTables[name] = tables[name]
+			}
+			componentNames, err := sortTables(componentTables, breakCycles)
+			if err != nil {
+				return nil, nil, err
+			}
+			names = append(names, componentNames...)
+		}
+		return names, deferredForeignKeys(names, tables, false), nil
+	}
+	names, err := sortTables(tables, breakCycles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return names, deferredForeignKeys(names, tables, false), nil
+}
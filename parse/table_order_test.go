@@ -0,0 +1,100 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func foreignKey(name, refTable string) *Constraint {
+	return &Constraint{
+		Name:      name,
+		Kind:      ForeignKeyConstraint,
+		Columns:   []string{refTable + "_id"},
+		RefTable:  refTable,
+		Statement: "CONSTRAINT " + name + " FOREIGN KEY (" + refTable + "_id) REFERENCES " + refTable + "(id)",
+	}
+}
+
+func TestOrderTables(t *testing.T) {
+	tables := map[string]*Table{
+		"users":    {Position: 3},
+		"orders":   {Position: 1, Constraints: map[string]*Constraint{"orders_users_fkey": foreignKey("orders_users_fkey", "users")}},
+		"accounts": {Position: 2, Constraints: map[string]*Constraint{"accounts_users_fkey": foreignKey("accounts_users_fkey", "users")}},
+		"audit":    {Position: 4},
+	}
+
+	tests := []struct {
+		name             string
+		input            string
+		expectedNames    []string
+		expectedDeferred map[string]map[string]bool
+	}{
+		{
+			name:             "Topological",
+			input:            TableOrderTopological,
+			expectedNames:    []string{"audit", "users", "accounts", "orders"},
+			expectedDeferred: map[string]map[string]bool{},
+		},
+		{
+			name:          "Alphabetical",
+			input:         TableOrderAlphabetical,
+			expectedNames: []string{"accounts", "audit", "orders", "users"},
+			expectedDeferred: map[string]map[string]bool{
+				"accounts": {"accounts_users_fkey": true},
+				"orders":   {"orders_users_fkey": true},
+			},
+		},
+		{
+			name:          "Dump",
+			input:         TableOrderDump,
+			expectedNames: []string{"orders", "accounts", "users", "audit"},
+			expectedDeferred: map[string]map[string]bool{
+				"accounts": {"accounts_users_fkey": true},
+				"orders":   {"orders_users_fkey": true},
+			},
+		},
+		{
+			name:             "Component",
+			input:            TableOrderComponent,
+			expectedNames:    []string{"users", "accounts", "orders", "audit"},
+			expectedDeferred: map[string]map[string]bool{},
+		},
+	}
+	for _, test := range tests {
+		names, deferred, err := orderTables(tables, test.input, false)
+		if err != nil {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(names, test.expectedNames) {
+			t.Error(test.name + " - names error")
+		} else if !cmp.Equal(deferred, test.expectedDeferred) {
+			t.Error(test.name + " - deferred error")
+		}
+	}
+}
+
+func TestSortTablesCycles(t *testing.T) {
+	newTables := func() map[string]*Table {
+		return map[string]*Table{
+			"authors": {Constraints: map[string]*Constraint{"authors_books_fkey": foreignKey("authors_books_fkey", "books")}},
+			"books":   {Constraints: map[string]*Constraint{"books_editors_fkey": foreignKey("books_editors_fkey", "editors")}},
+			"editors": {Constraints: map[string]*Constraint{"editors_authors_fkey": foreignKey("editors_authors_fkey", "authors")}},
+			"reviews": {Constraints: map[string]*Constraint{"reviews_books_fkey": foreignKey("reviews_books_fkey", "books")}},
+		}
+	}
+
+	_, err := sortTables(newTables(), false)
+	if err == nil || err.Error() != "sorting tables - foreign key cycle authors -> books -> editors -> authors" {
+		t.Error("Foreign key cycle - error")
+	}
+
+	tables := newTables()
+	names, deferred, err := orderTables(tables, TableOrderTopological, true)
+	if err != nil {
+		t.Error("Break foreign key cycle - fatal error")
+	} else if !cmp.Equal(names, []string{"editors", "books", "authors", "reviews"}) {
+		t.Error("Break foreign key cycle - names error")
+	} else if !cmp.Equal(deferred, map[string]map[string]bool{"editors": {"editors_authors_fkey": true}}) {
+		t.Error("Break foreign key cycle - deferred error")
+	}
+}
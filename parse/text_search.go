@@ -0,0 +1,107 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TextSearchDictionary is the struct containing logical aspects of a text search dictionary definition
+type TextSearchDictionary struct {
+	Name      string
+	Schema    string
+	Template  string
+	Options   map[string]string
+	Statement string
+}
+
+// TextSearchConfiguration is the struct containing logical aspects of a text search configuration definition
+type TextSearchConfiguration struct {
+	Name      string
+	Schema    string
+	Parser    string
+	Mappings  []string
+	Statement string
+}
+
+// Statements returns the create statement of the text search configuration followed by its mapping statements
+func (c *TextSearchConfiguration) Statements() []string {
+	stmts := []string{c.Statement}
+	for _, mapping := range c.Mappings {
+		stmts = append(stmts, "ALTER TEXT SEARCH CONFIGURATION "+c.Name+" "+mapping+";")
+	}
+	return stmts
+}
+
+// StoreTextSearchDictionaries parses sql statements for text search dictionaries and maps them by name.
+// It then returns the remaining lines and text search dictionaries.
+func StoreTextSearchDictionaries(lines []string) ([]string, map[string]*TextSearchDictionary, error) {
+	dictionaries := make(map[string]*TextSearchDictionary)
+	if len(lines) == 0 {
+		return lines, dictionaries, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE TEXT SEARCH DICTIONARY ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		dictionary := &TextSearchDictionary{
+			Options:   parseOptions(line),
+			Statement: line,
+		}
+		dictionary.Name, dictionary.Schema = removeAccessModifier(strings.Split(line, " ")[4])
+		dictionary.Template = dictionary.Options["template"]
+		if len(dictionary.Template) == 0 {
+			return lines, dictionaries, fmt.Errorf("storing text search dictionaries - missing template")
+		}
+		if len(dictionary.Schema) > 0 {
+			dictionary.Statement = strings.Replace(line, dictionary.Schema+".", "", -1)
+		}
+
+		dictionaries[dictionary.Name] = dictionary
+	}
+
+	return bufferLines, dictionaries, nil
+}
+
+// StoreTextSearchConfigurations parses sql statements for text search configurations and their mappings and maps
+// them by name.
+// It then returns the remaining lines and text search configurations.
+func StoreTextSearchConfigurations(lines []string) ([]string, map[string]*TextSearchConfiguration, error) {
+	configurations := make(map[string]*TextSearchConfiguration)
+	if len(lines) == 0 {
+		return lines, configurations, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "CREATE TEXT SEARCH CONFIGURATION ") {
+			configuration := &TextSearchConfiguration{
+				Parser:    parseOptions(line)["parser"],
+				Statement: line,
+			}
+			configuration.Name, configuration.Schema = removeAccessModifier(strings.Split(line, " ")[4])
+			if len(configuration.Schema) > 0 {
+				configuration.Statement = strings.Replace(line, configuration.Schema+".", "", -1)
+			}
+			configurations[configuration.Name] = configuration
+		} else if strings.HasPrefix(line, "ALTER TEXT SEARCH CONFIGURATION ") {
+			name, modifier := removeAccessModifier(strings.Split(line, " ")[4])
+			configuration, ok := configurations[name]
+			if !ok {
+				return lines, configurations, fmt.Errorf("storing text search configurations - configuration does not exist")
+			}
+			mapping := strings.TrimSuffix(strings.Join(strings.Split(line, " ")[5:], " "), ";")
+			if len(modifier) > 0 {
+				mapping = strings.Replace(mapping, modifier+".", "", -1)
+			}
+			configuration.Mappings = append(configuration.Mappings, mapping)
+		} else {
+			bufferLines = append(bufferLines, line)
+		}
+	}
+
+	return bufferLines, configurations, nil
+}
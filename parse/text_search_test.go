@@ -0,0 +1,120 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStoreTextSearchDictionaries(t *testing.T) {
+	dictionary := "CREATE TEXT SEARCH DICTIONARY public.english_nostop ( TEMPLATE = pg_catalog.snowball, language = 'english' );"
+
+	tests := []struct {
+		name                 string
+		input                []string
+		expectedDictionaries map[string]*TextSearchDictionary
+		expectedLines        []string
+		expectedError        error
+	}{
+		{
+			name:                 "No input",
+			input:                []string{},
+			expectedDictionaries: map[string]*TextSearchDictionary{},
+			expectedLines:        []string{},
+			expectedError:        nil,
+		},
+		{
+			name:                 "Missing template",
+			input:                []string{"CREATE TEXT SEARCH DICTIONARY public.broken ( language = 'english' );"},
+			expectedDictionaries: map[string]*TextSearchDictionary{},
+			expectedLines:        []string{"CREATE TEXT SEARCH DICTIONARY public.broken ( language = 'english' );"},
+			expectedError:        fmt.Errorf("storing text search dictionaries - missing template"),
+		},
+		{
+			name:  "Dictionary statements with extra lines",
+			input: []string{"abc", dictionary, "def"},
+			expectedDictionaries: map[string]*TextSearchDictionary{
+				"english_nostop": {
+					Name:      "english_nostop",
+					Schema:    "public",
+					Template:  "pg_catalog.snowball",
+					Options:   map[string]string{"template": "pg_catalog.snowball", "language": "'english'"},
+					Statement: "CREATE TEXT SEARCH DICTIONARY english_nostop ( TEMPLATE = pg_catalog.snowball, language = 'english' );",
+				},
+			},
+			expectedLines: []string{"abc", "def"},
+			expectedError: nil,
+		},
+	}
+	for _, test := range tests {
+		lines, dictionaries, err := StoreTextSearchDictionaries(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(dictionaries, test.expectedDictionaries) {
+			t.Error(test.name + " - dictionaries error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
+
+func TestStoreTextSearchConfigurations(t *testing.T) {
+	configuration := "CREATE TEXT SEARCH CONFIGURATION public.search ( PARSER = pg_catalog.\"default\" );"
+	mapping := "ALTER TEXT SEARCH CONFIGURATION public.search ADD MAPPING FOR asciiword WITH public.english_nostop;"
+	expectedConfiguration := &TextSearchConfiguration{
+		Name:      "search",
+		Schema:    "public",
+		Parser:    "pg_catalog.\"default\"",
+		Mappings:  []string{"ADD MAPPING FOR asciiword WITH english_nostop"},
+		Statement: "CREATE TEXT SEARCH CONFIGURATION search ( PARSER = pg_catalog.\"default\" );",
+	}
+
+	tests := []struct {
+		name                   string
+		input                  []string
+		expectedConfigurations map[string]*TextSearchConfiguration
+		expectedLines          []string
+		expectedError          error
+	}{
+		{
+			name:                   "No input",
+			input:                  []string{},
+			expectedConfigurations: map[string]*TextSearchConfiguration{},
+			expectedLines:          []string{},
+			expectedError:          nil,
+		},
+		{
+			name:                   "Configuration does not exist",
+			input:                  []string{mapping},
+			expectedConfigurations: map[string]*TextSearchConfiguration{},
+			expectedLines:          []string{mapping},
+			expectedError:          fmt.Errorf("storing text search configurations - configuration does not exist"),
+		},
+		{
+			name:                   "Configuration statements with extra lines",
+			input:                  []string{"abc", configuration, mapping, "def"},
+			expectedConfigurations: map[string]*TextSearchConfiguration{"search": expectedConfiguration},
+			expectedLines:          []string{"abc", "def"},
+			expectedError:          nil,
+		},
+	}
+	for _, test := range tests {
+		lines, configurations, err := StoreTextSearchConfigurations(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(configurations, test.expectedConfigurations) {
+			t.Error(test.name + " - configurations error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+
+	expectedStatements := []string{
+		"CREATE TEXT SEARCH CONFIGURATION search ( PARSER = pg_catalog.\"default\" );",
+		"ALTER TEXT SEARCH CONFIGURATION search ADD MAPPING FOR asciiword WITH english_nostop;",
+	}
+	if !cmp.Equal(expectedConfiguration.Statements(), expectedStatements) {
+		t.Error("Configuration statements error")
+	}
+}
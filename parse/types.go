@@ -0,0 +1,115 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of user defined types
+const (
+	EnumType      = "enum"
+	CompositeType = "composite"
+	RangeType     = "range"
+)
+
+// Type is the struct containing logical aspects of an enum, composite or range type definition
+type Type struct {
+	Name      string
+	Schema    string
+	Kind      string
+	Statement string
+}
+
+// Domain is the struct containing logical aspects of a domain definition
+type Domain struct {
+	Name      string
+	Schema    string
+	BaseType  string
+	Statement string
+}
+
+// Labels returns the quoted labels of an enum type in order
+func (t *Type) Labels() []string {
+	open := strings.Index(t.Statement, "(")
+	closing := matchingParen(t.Statement, open)
+	if t.Kind != EnumType || open == -1 || closing == -1 {
+		return nil
+	}
+	return splitTopLevel(t.Statement[open+1:closing], ',')
+}
+
+// StoreTypes parses sql statements for enum, composite and range types and maps them by name.
+// It then returns the remaining lines and types.
+// Note: Must run after multi-line statements are squashed.
+func StoreTypes(lines []string) ([]string, map[string]*Type, error) {
+	types := make(map[string]*Type)
+	if len(lines) == 0 {
+		return lines, types, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE TYPE ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		// CREATE TYPE name AS ENUM (...); CREATE TYPE name AS (...); CREATE TYPE name AS RANGE (...);
+		tokens := strings.Split(line, " ")
+		if len(tokens) < 5 || tokens[3] != "AS" {
+			return lines, types, fmt.Errorf("storing types - unsupported type definition")
+		}
+		typ := &Type{Kind: CompositeType, Statement: line}
+		switch tokens[4] {
+		case "ENUM":
+			typ.Kind = EnumType
+		case "RANGE":
+			typ.Kind = RangeType
+		}
+		typ.Name, typ.Schema = removeAccessModifier(tokens[2])
+		if len(typ.Schema) > 0 {
+			typ.Statement = strings.Replace(line, typ.Schema+".", "", -1)
+		}
+
+		types[typ.Name] = typ
+	}
+
+	return bufferLines, types, nil
+}
+
+// StoreDomains parses sql statements for domains and maps them by name.
+// It then returns the remaining lines and domains.
+// Note: Must run after multi-line statements are squashed.
+func StoreDomains(lines []string) ([]string, map[string]*Domain, error) {
+	domains := make(map[string]*Domain)
+	if len(lines) == 0 {
+		return lines, domains, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE DOMAIN ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		// squashed constraint lines are indented by tabs
+		line = strings.Replace(line, " \t", " ", -1)
+
+		// CREATE DOMAIN name AS type [constraints];
+		tokens := strings.Split(line, " ")
+		if len(tokens) < 5 || tokens[3] != "AS" {
+			return lines, domains, fmt.Errorf("storing domains - missing base type")
+		}
+		domain := &Domain{Statement: line}
+		domain.Name, domain.Schema = removeAccessModifier(tokens[2])
+		domain.BaseType, _ = splitColumnStatement(strings.TrimSuffix(strings.Join(tokens[4:], " "), ";"))
+		if len(domain.Schema) > 0 {
+			domain.Statement = strings.Replace(line, domain.Schema+".", "", -1)
+		}
+
+		domains[domain.Name] = domain
+	}
+
+	return bufferLines, domains, nil
+}
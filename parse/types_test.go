@@ -0,0 +1,110 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStoreTypes(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         []string
+		expectedLines []string
+		expectedTypes map[string]*Type
+		hasError      bool
+	}{
+		{
+			name: "Enum, composite and range types",
+			input: []string{
+				"CREATE TYPE public.mood AS ENUM ( 'sad', 'ok' );",
+				"CREATE TYPE public.pair AS ( a integer, b public.mood );",
+				"CREATE TYPE public.floatrange AS RANGE ( subtype = double precision );",
+				"CREATE TABLE users ( id integer );",
+			},
+			expectedLines: []string{"CREATE TABLE users ( id integer );"},
+			expectedTypes: map[string]*Type{
+				"mood": {Name: "mood", Schema: "public", Kind: EnumType,
+					Statement: "CREATE TYPE mood AS ENUM ( 'sad', 'ok' );"},
+				"pair": {Name: "pair", Schema: "public", Kind: CompositeType,
+					Statement: "CREATE TYPE pair AS ( a integer, b mood );"},
+				"floatrange": {Name: "floatrange", Schema: "public", Kind: RangeType,
+					Statement: "CREATE TYPE floatrange AS RANGE ( subtype = double precision );"},
+			},
+		},
+		{
+			name:     "Base type",
+			input:    []string{"CREATE TYPE public.point3d;"},
+			hasError: true,
+		},
+	}
+	for _, test := range tests {
+		lines, types, err := StoreTypes(test.input)
+		if test.hasError {
+			if err == nil {
+				t.Error(test.name + " - error expected")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(test.name + " - unexpected error")
+		} else if !cmp.Equal(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		} else if !cmp.Equal(types, test.expectedTypes) {
+			t.Error(test.name + " - types error")
+		}
+	}
+
+	enum := &Type{Kind: EnumType, Statement: "CREATE TYPE mood AS ENUM ( 'sad', 'ok, fine' );"}
+	if !cmp.Equal(enum.Labels(), []string{"'sad'", "'ok, fine'"}) {
+		t.Error("Enum labels error")
+	}
+	composite := &Type{Kind: CompositeType, Statement: "CREATE TYPE pair AS ( a integer, b mood );"}
+	if composite.Labels() != nil {
+		t.Error("Composite labels error")
+	}
+}
+
+func TestStoreDomains(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           []string
+		expectedDomains map[string]*Domain
+		hasError        bool
+	}{
+		{
+			name: "Domain with constraint",
+			input: []string{
+				"CREATE DOMAIN public.positive AS integer \tCONSTRAINT positive_check CHECK (public.is_positive(VALUE));",
+				"CREATE DOMAIN email AS character varying(255) NOT NULL;",
+			},
+			expectedDomains: map[string]*Domain{
+				"positive": {Name: "positive", Schema: "public", BaseType: "integer",
+					Statement: "CREATE DOMAIN positive AS integer CONSTRAINT positive_check CHECK (is_positive(VALUE));"},
+				"email": {Name: "email", BaseType: "character varying(255)",
+					Statement: "CREATE DOMAIN email AS character varying(255) NOT NULL;"},
+			},
+		},
+		{
+			name:     "Missing base type",
+			input:    []string{"CREATE DOMAIN public.positive;"},
+			hasError: true,
+		},
+	}
+	for _, test := range tests {
+		lines, domains, err := StoreDomains(test.input)
+		if test.hasError {
+			if err == nil {
+				t.Error(test.name + " - error expected")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(test.name + " - unexpected error")
+		} else if len(lines) != 0 {
+			t.Error(test.name + " - lines error")
+		} else if !cmp.Equal(domains, test.expectedDomains) {
+			t.Error(test.name + " - domains error")
+		}
+	}
+}
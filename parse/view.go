@@ -0,0 +1,113 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// View is the struct containing logical aspects of a view or materialized view definition
+type View struct {
+	Name           string
+	Schema         string
+	IsMaterialized bool
+	Query          string
+	Rules          []string
+	Statement      string
+}
+
+func isCreateView(line string) bool {
+	for _, prefix := range []string{"CREATE VIEW ", "CREATE OR REPLACE VIEW ", "CREATE MATERIALIZED VIEW "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// StoreViews parses sql statements for views and maps them by name.
+// It then returns the remaining lines and views.
+// Note: Must run before any multi-line statements are squashed to keep the formatting of view queries.
+func StoreViews(lines []string) ([]string, map[string]*View, error) {
+	views := make(map[string]*View)
+	if len(lines) == 0 {
+		return lines, views, nil
+	}
+
+	var bufferLines []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if !isCreateView(line) {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		j, stmt := i, line
+		for statementEnd(stmt) == -1 {
+			j++
+			if j == len(lines) {
+				return lines, views, fmt.Errorf("storing views - view is not terminated")
+			}
+			stmt += "\n" + lines[j]
+		}
+
+		view := &View{
+			IsMaterialized: strings.HasPrefix(line, "CREATE MATERIALIZED VIEW "),
+			Statement:      stmt,
+		}
+		rest := stmt[strings.Index(stmt, "VIEW ")+len("VIEW "):]
+		view.Name, view.Schema = removeAccessModifier(strings.Split(rest, " ")[0])
+		index := indexKeyword(rest, "AS")
+		if index == -1 {
+			return lines, views, fmt.Errorf("storing views - missing view query")
+		}
+		view.Query = strings.TrimSpace(rest[index+len("AS") : statementEnd(rest)])
+		if view.IsMaterialized {
+			view.Query = strings.TrimSpace(strings.TrimSuffix(view.Query, "WITH NO DATA"))
+		}
+		if len(view.Schema) > 0 {
+			view.Statement = strings.Replace(stmt, view.Schema+".", "", -1)
+		}
+
+		views[view.Name] = view
+		i = j
+	}
+
+	return bufferLines, views, nil
+}
+
+// StoreRules parses sql statements for rules and maps them to their tables or views.
+// It then returns the remaining lines.
+func StoreRules(lines []string, tables map[string]*Table, views map[string]*View) ([]string, error) {
+	if len(lines) == 0 {
+		return lines, nil
+	}
+
+	var bufferLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "CREATE RULE ") && !strings.HasPrefix(line, "CREATE OR REPLACE RULE ") {
+			bufferLines = append(bufferLines, line)
+			continue
+		}
+
+		index := strings.Index(line, " TO ")
+		if index == -1 {
+			return lines, fmt.Errorf("storing rules - missing rule relation")
+		}
+		relation := strings.Split(line[index+len(" TO "):], " ")[0]
+		relationName, modifier := removeAccessModifier(relation)
+		rule := line
+		if len(modifier) > 0 {
+			rule = strings.Replace(line, modifier+".", "", -1)
+		}
+
+		if table, ok := tables[relationName]; ok {
+			table.Rules = append(table.Rules, rule)
+		} else if view, ok := views[relationName]; ok {
+			view.Rules = append(view.Rules, rule)
+		} else {
+			return lines, fmt.Errorf("storing rules - table or view does not exist")
+		}
+	}
+
+	return bufferLines, nil
+}
@@ -0,0 +1,143 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStoreViews(t *testing.T) {
+	view := []string{
+		"CREATE VIEW public.active_customers AS",
+		" SELECT customers.id",
+		"   FROM public.customers",
+		"  WHERE (customers.email IS NOT NULL);",
+	}
+	materializedView := []string{
+		"CREATE MATERIALIZED VIEW public.order_totals AS",
+		" SELECT sum(orders.total) AS total",
+		"   FROM public.orders",
+		"  WITH NO DATA;",
+	}
+	expectedView := &View{
+		Name:      "active_customers",
+		Schema:    "public",
+		Query:     "SELECT customers.id\n   FROM public.customers\n  WHERE (customers.email IS NOT NULL)",
+		Statement: "CREATE VIEW active_customers AS\n SELECT customers.id\n   FROM customers\n  WHERE (customers.email IS NOT NULL);",
+	}
+	expectedMaterializedView := &View{
+		Name:           "order_totals",
+		Schema:         "public",
+		IsMaterialized: true,
+		Query:          "SELECT sum(orders.total) AS total\n   FROM public.orders",
+		Statement:      "CREATE MATERIALIZED VIEW order_totals AS\n SELECT sum(orders.total) AS total\n   FROM orders\n  WITH NO DATA;",
+	}
+
+	tests := []struct {
+		name          string
+		input         []string
+		expectedViews map[string]*View
+		expectedLines []string
+		expectedError error
+	}{
+		{
+			name:          "No input",
+			input:         []string{},
+			expectedViews: map[string]*View{},
+			expectedLines: []string{},
+			expectedError: nil,
+		},
+		{
+			name:  "View statements with extra lines",
+			input: append(append(append([]string{"abc"}, view...), materializedView...), "def"),
+			expectedViews: map[string]*View{
+				"active_customers": expectedView,
+				"order_totals":     expectedMaterializedView,
+			},
+			expectedLines: []string{"abc", "def"},
+			expectedError: nil,
+		},
+		{
+			name:          "View is not terminated",
+			input:         view[:2],
+			expectedViews: map[string]*View{},
+			expectedLines: view[:2],
+			expectedError: fmt.Errorf("storing views - view is not terminated"),
+		},
+	}
+	for _, test := range tests {
+		lines, views, err := StoreViews(test.input)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !cmp.Equal(views, test.expectedViews) {
+			t.Error(test.name + " - views error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}
+
+func TestStoreRules(t *testing.T) {
+	tableRule := "CREATE RULE protect_orders AS ON DELETE TO public.orders DO INSTEAD NOTHING;"
+	viewRule := "CREATE RULE insert_customer AS ON INSERT TO public.active_customers DO INSTEAD INSERT INTO public.customers (id) VALUES (new.id);"
+
+	tests := []struct {
+		name           string
+		inputLines     []string
+		inputTables    map[string]*Table
+		inputViews     map[string]*View
+		expectedTables map[string]*Table
+		expectedViews  map[string]*View
+		expectedLines  []string
+		expectedError  error
+	}{
+		{
+			name:           "No input",
+			inputLines:     []string{},
+			inputTables:    map[string]*Table{"orders": {}},
+			inputViews:     map[string]*View{},
+			expectedTables: map[string]*Table{"orders": {}},
+			expectedViews:  map[string]*View{},
+			expectedLines:  []string{},
+			expectedError:  nil,
+		},
+		{
+			name:           "Table or view does not exist",
+			inputLines:     []string{tableRule},
+			inputTables:    map[string]*Table{"customers": {}},
+			inputViews:     map[string]*View{},
+			expectedTables: map[string]*Table{"customers": {}},
+			expectedViews:  map[string]*View{},
+			expectedLines:  []string{tableRule},
+			expectedError:  fmt.Errorf("storing rules - table or view does not exist"),
+		},
+		{
+			name:           "Rule statements with extra lines",
+			inputLines:     []string{"abc", tableRule, viewRule, "def"},
+			inputTables:    map[string]*Table{"orders": {}},
+			inputViews:     map[string]*View{"active_customers": {Name: "active_customers"}},
+			expectedTables: map[string]*Table{"orders": {Rules: []string{"CREATE RULE protect_orders AS ON DELETE TO orders DO INSTEAD NOTHING;"}}},
+			expectedViews: map[string]*View{
+				"active_customers": {
+					Name:  "active_customers",
+					Rules: []string{"CREATE RULE insert_customer AS ON INSERT TO active_customers DO INSTEAD INSERT INTO customers (id) VALUES (new.id);"},
+				},
+			},
+			expectedLines: []string{"abc", "def"},
+			expectedError: nil,
+		},
+	}
+	for _, test := range tests {
+		lines, err := StoreRules(test.inputLines, test.inputTables, test.inputViews)
+		if err != nil && (test.expectedError == nil || err.Error() != test.expectedError.Error()) {
+			t.Error(test.name + " - fatal error")
+		} else if !similarTables(test.inputTables, test.expectedTables) {
+			t.Error(test.name + " - tables error")
+		} else if !cmp.Equal(test.inputViews, test.expectedViews) {
+			t.Error(test.name + " - views error")
+		} else if !similarLines(lines, test.expectedLines) {
+			t.Error(test.name + " - lines error")
+		}
+	}
+}